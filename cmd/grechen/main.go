@@ -1,10 +1,13 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/heywinit/grechen/internal/cli"
 	"github.com/heywinit/grechen/internal/extract"
@@ -31,37 +34,111 @@ func main() {
 		dataDir = filepath.Join(home, ".grechen")
 	}
 
+	// Get time zone (default: system zone)
+	loc, err := getLocation()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid GRECHEN_TIMEZONE: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Initialize store
-	s, err := store.New(dataDir)
+	s, err := store.Open(dataDir, loc)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: failed to initialize store: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Initialize LLM provider (default: gemini)
-	llmProvider := getLLMProvider()
-	if llmProvider == nil {
-		fmt.Fprintf(os.Stderr, "error: failed to initialize LLM provider\n")
+	// Initialize extractor (default: gemini, falling back to offline rules)
+	extractor, err := getExtractor(s)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Days a soft deadline may slip before it counts as missed
+	softGraceDays, err := getSoftGraceDays()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid GRECHEN_SOFT_GRACE_DAYS: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Whether natural-language input is confirmed before it's recorded
+	confirm, err := getConfirm()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid GRECHEN_CONFIRM: %v\n", err)
 		os.Exit(1)
 	}
-	extractor := extract.NewLLMExtractor(llmProvider)
 
 	// Initialize components
-	r := rules.New(s)
+	r := rules.New(s, softGraceDays)
 	st := stats.New(s)
 	p := patterns.New(s, st)
 	c := cli.New(s, extractor, r, st, p)
 
-	// Parse arguments
+	// Parse arguments; flags before natural-language input say how it's
+	// recorded
 	args := os.Args[1:]
+	mode := cli.WriteDirect
+	if confirm {
+		mode = cli.WriteConfirm
+	}
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		if at, ok := strings.CutPrefix(args[0], "--at"); ok && (at == "" || at[0] == '=') {
+			if at == "" {
+				if len(args) < 2 {
+					fmt.Fprintf(os.Stderr, "error: --at needs a time, e.g. --at \"yesterday 4pm\"\n")
+					os.Exit(1)
+				}
+				at, args = args[1], args[1:]
+			}
+			if err := c.SetAt(strings.TrimPrefix(at, "=")); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			args = args[1:]
+			continue
+		}
+		switch args[0] {
+		case "--dry-run":
+			mode = cli.WriteDryRun
+		case "--confirm":
+			mode = cli.WriteConfirm
+		case "--yes":
+			mode = cli.WriteDirect
+		default:
+			fmt.Fprintf(os.Stderr, "error: unknown flag %s\n", args[0])
+			os.Exit(1)
+		}
+		args = args[1:]
+	}
+	c.SetWriteMode(mode)
+
 	if len(args) == 0 {
-		fmt.Fprintf(os.Stderr, "usage: grechen <command> [args...]\n")
-		fmt.Fprintf(os.Stderr, "commands: <natural language> | goodnight | review | today | commitments | todo | projects | people | thats-wrong | setup\n")
+		fmt.Fprintf(os.Stderr, "usage: grechen [--dry-run | --confirm | --yes] [--at WHEN] <command> [args...]\n")
+		fmt.Fprintf(os.Stderr, "commands: <natural language> | goodnight | review | today | agenda | commitments | todo | projects | project | people | thats-wrong | undo | redo | inbox | show | done | drop | snooze | reopen | renegotiate | tick | fmt | setup | migrate\n")
 		os.Exit(1)
 	}
 
 	command := args[0]
 
+	// Make sure existing data matches the configured time zone
+	if command != "migrate" {
+		if err := c.CheckTimezone(); err != nil {
+			fmt.Fprintf(os.Stderr, "error: time zone check failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Mark commitments whose deadline has passed as violated; a dry run
+	// writes nothing, and undo and redo step through what earlier sweeps
+	// marked instead of recording a new one
+	if command != "migrate" && command != "tick" && command != "undo" && command != "redo" && mode != cli.WriteDryRun {
+		if err := c.Sweep(); err != nil {
+			fmt.Fprintf(os.Stderr, "error: violation sweep failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Route to appropriate handler
 	var handlerErr error
 	switch command {
@@ -73,16 +150,44 @@ func main() {
 		handlerErr = c.HandleReview()
 	case "today":
 		handlerErr = c.HandleToday()
+	case "agenda":
+		handlerErr = c.HandleAgenda(args[1:])
 	case "commitments":
-		handlerErr = c.HandleCommitments()
+		handlerErr = c.HandleCommitments(args[1:])
 	case "todo":
 		handlerErr = c.HandleTodo()
 	case "projects":
 		handlerErr = c.HandleProjects()
+	case "project":
+		handlerErr = c.HandleProject(args[1:])
 	case "people":
 		handlerErr = c.HandlePeople()
 	case "thats-wrong":
-		handlerErr = c.HandleThatsWrong()
+		handlerErr = c.HandleThatsWrong(args[1:])
+	case "undo":
+		handlerErr = c.HandleUndo(args[1:])
+	case "redo":
+		handlerErr = c.HandleRedo(args[1:])
+	case "tick":
+		handlerErr = c.HandleTick()
+	case "show":
+		handlerErr = c.HandleShow(args[1:])
+	case "inbox":
+		handlerErr = c.HandleInbox(args[1:])
+	case "renegotiate":
+		handlerErr = c.HandleRenegotiate(args[1:])
+	case "done":
+		handlerErr = c.HandleDone(args[1:])
+	case "drop":
+		handlerErr = c.HandleDrop(args[1:])
+	case "snooze":
+		handlerErr = c.HandleSnooze(args[1:])
+	case "reopen":
+		handlerErr = c.HandleReopen(args[1:])
+	case "fmt":
+		handlerErr = c.HandleFmt(args[1:])
+	case "migrate":
+		handlerErr = c.HandleMigrate(args[1:])
 	default:
 		// Treat as natural language input
 		input := strings.Join(args, " ")
@@ -95,23 +200,103 @@ func main() {
 	}
 }
 
-func getLLMProvider() llm.Provider {
+// getSoftGraceDays returns GRECHEN_SOFT_GRACE_DAYS, defaulting to
+// rules.DefaultSoftGraceDays
+func getSoftGraceDays() (int, error) {
+	v := os.Getenv("GRECHEN_SOFT_GRACE_DAYS")
+	if v == "" {
+		return rules.DefaultSoftGraceDays, nil
+	}
+	days, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, err
+	}
+	if days < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return days, nil
+}
+
+// getConfirm returns GRECHEN_CONFIRM, false when unset
+func getConfirm() (bool, error) {
+	v := os.Getenv("GRECHEN_CONFIRM")
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+// getLocation returns the user's time zone from GRECHEN_TIMEZONE, falling
+// back to the system zone
+func getLocation() (*time.Location, error) {
+	if name := os.Getenv("GRECHEN_TIMEZONE"); name != "" {
+		return time.LoadLocation(name)
+	}
+
+	// Prefer a named zone over "Local" so the name recorded with the data
+	// still means the same thing if the machine's zone changes
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			return loc, nil
+		}
+	}
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if i := strings.Index(target, "zoneinfo/"); i >= 0 {
+			if loc, err := time.LoadLocation(target[i+len("zoneinfo/"):]); err == nil {
+				return loc, nil
+			}
+		}
+	}
+	return time.Local, nil
+}
+
+func getExtractor(entities extract.EntitySource) (extract.Extractor, error) {
 	providerType := os.Getenv("GRECHEN_LLM_PROVIDER")
 	if providerType == "" {
 		providerType = "gemini"
 	}
+	if providerType == "offline" {
+		return extract.NewOfflineExtractor(), nil
+	}
+
+	fallback := os.Getenv("GRECHEN_OFFLINE_FALLBACK") != "false"
+
+	provider, err := getLLMProvider(providerType)
+	if err != nil {
+		// A misspelled provider is a configuration mistake, not an outage
+		if !fallback || errors.Is(err, errUnknownProvider) {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "warning: %v, using offline extraction\n", err)
+		return extract.NewOfflineExtractor(), nil
+	}
+
+	extractor := extract.NewLLMExtractor(provider, entities)
+	if !fallback {
+		return extractor, nil
+	}
+	return extract.NewFallbackExtractor(extractor, extract.NewOfflineExtractor(), func(err error) {
+		fmt.Fprintf(os.Stderr, "warning: %v, using offline extraction\n", err)
+	}), nil
+}
 
+var errUnknownProvider = errors.New("unknown provider")
+
+func getLLMProvider(providerType string) (llm.Provider, error) {
 	switch providerType {
 	case "gemini":
 		provider, err := llm.NewGeminiProvider()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "error: failed to initialize gemini provider: %v\n", err)
-			fmt.Fprintf(os.Stderr, "hint: set GEMINI_API_KEY environment variable\n")
-			return nil
+			return nil, fmt.Errorf("failed to initialize gemini provider: %w (hint: set GEMINI_API_KEY)", err)
+		}
+		return provider, nil
+	case "openai":
+		provider, err := llm.NewOpenAIProvider()
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize openai provider: %w (hint: set OPENAI_API_KEY, or OPENAI_BASE_URL for a local server)", err)
 		}
-		return provider
+		return provider, nil
 	default:
-		fmt.Fprintf(os.Stderr, "error: unknown provider %s (only 'gemini' is supported)\n", providerType)
-		return nil
+		return nil, fmt.Errorf("%w %s (supported: gemini, openai, offline)", errUnknownProvider, providerType)
 	}
 }
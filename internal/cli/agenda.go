@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/dates"
+)
+
+// agendaDays is how far ahead the agenda looks by default, and how far
+// ahead today's upcoming events go
+const agendaDays = 7
+
+var nextDays = regexp.MustCompile(`^(?:next\s+)?(\d+)\s+days?$`)
+
+// HandleAgenda lists the events in a range of days
+//
+//	grechen agenda [range]
+//
+// The range is "week" (the default: today and the 6 days after), "month",
+// "next N days", a single day ("today", "friday", 2026-03-06), or two days
+// joined by ".." ("monday..friday"), both included.
+func (c *CLI) HandleAgenda(args []string) error {
+	from, to, err := c.agendaRange(strings.TrimSpace(strings.Join(args, " ")))
+	if err != nil {
+		return err
+	}
+
+	events, err := c.store.ListEventsBetween(from, to)
+	if err != nil {
+		return err
+	}
+
+	last := to.AddDate(0, 0, -1)
+	if last.Equal(from) {
+		fmt.Printf("agenda (%s)\n", from.Format("2006-01-02"))
+	} else {
+		fmt.Printf("agenda (%s to %s)\n", from.Format("2006-01-02"), last.Format("2006-01-02"))
+	}
+	if len(events) == 0 {
+		fmt.Println("  nothing scheduled")
+		return nil
+	}
+
+	day := time.Time{}
+	for _, event := range events {
+		start := c.store.Day(event.Start)
+		if start.Before(from) {
+			start = from
+		}
+		if !start.Equal(day) {
+			day = start
+			fmt.Printf("\n%s\n", strings.ToLower(day.Format("Mon 2006-01-02")))
+		}
+		c.printEvent(event, false)
+	}
+	return nil
+}
+
+// agendaRange turns an agenda range into the days it covers: midnight of
+// the first, and midnight after the last
+func (c *CLI) agendaRange(expr string) (time.Time, time.Time, error) {
+	now := c.store.Now()
+	today := c.store.Day(now)
+	expr = strings.ToLower(expr)
+
+	switch expr {
+	case "", "week":
+		return today, today.AddDate(0, 0, agendaDays), nil
+	case "month":
+		return today, today.AddDate(0, 1, 0), nil
+	}
+	if m := nextDays.FindStringSubmatch(expr); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n < 1 {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid agenda range %q", expr)
+		}
+		return today, today.AddDate(0, 0, n), nil
+	}
+
+	if first, last, ok := strings.Cut(expr, ".."); ok {
+		from, err := dates.Resolve(first, now)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid agenda start: %w", err)
+		}
+		to, err := dates.Resolve(last, now)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid agenda end: %w", err)
+		}
+		if to.Before(from) {
+			return time.Time{}, time.Time{}, fmt.Errorf("agenda range ends before it starts")
+		}
+		return from, to.AddDate(0, 0, 1), nil
+	}
+
+	day, err := dates.Resolve(expr, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid agenda range: %w", err)
+	}
+	return day, day.AddDate(0, 0, 1), nil
+}
+
+// printEvent prints an event on one line; withDay puts its day in front
+// of the time
+func (c *CLI) printEvent(event *core.Event, withDay bool) {
+	when := c.eventTimes(event)
+	if withDay {
+		when = c.eventWhen(event)
+	}
+	fmt.Printf("  %s  %s [%s]", when, event.Title, event.ID)
+	var with []string
+	if len(event.Attendees) > 0 {
+		with = append(with, "with "+strings.Join(event.Attendees, ", "))
+	}
+	if event.ProjectID != "" {
+		with = append(with, "project "+event.ProjectID)
+	}
+	if len(with) > 0 {
+		fmt.Printf(" (%s)", strings.Join(with, ", "))
+	}
+	fmt.Println()
+}
+
+// eventWhen is an event's day and times, e.g. "fri 2026-03-06 18:00-19:00"
+func (c *CLI) eventWhen(event *core.Event) string {
+	day := strings.ToLower(event.Start.In(c.store.Location()).Format("Mon 2006-01-02"))
+	return day + " " + c.eventTimes(event)
+}
+
+// eventTimes is an event's times of day: "18:00", "18:00-19:00" or
+// "all day". An end on a later day is given with its date.
+func (c *CLI) eventTimes(event *core.Event) string {
+	if event.AllDay {
+		return "all day"
+	}
+	start := event.Start.In(c.store.Location())
+	if event.End == nil {
+		return start.Format("15:04")
+	}
+	end := event.End.In(c.store.Location())
+	if c.store.Day(end).Equal(c.store.Day(start)) {
+		return start.Format("15:04") + "-" + end.Format("15:04")
+	}
+	return start.Format("15:04") + "-" + end.Format("2006-01-02 15:04")
+}
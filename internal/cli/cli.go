@@ -1,11 +1,13 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/dates"
 	"github.com/heywinit/grechen/internal/extract"
 	"github.com/heywinit/grechen/internal/patterns"
 	"github.com/heywinit/grechen/internal/rules"
@@ -14,14 +16,19 @@ import (
 )
 
 type CLI struct {
-	store    *store.Store
+	store    store.Store
 	extractor extract.Extractor
 	rules    *rules.Rules
 	stats    *stats.Stats
 	patterns *patterns.Patterns
+	mode     WriteMode // whether input is recorded right away, after confirmation or not at all
+	at       string    // when input happened, from --at: "2006-01-02 15:04" or a date
+	atPhrase string    // --at as given
+	unsaved  *core.Entry // input saved with the first operation recorded from it
+	entryIDs map[string]string // ID an input was given → ID it was saved under, when that had to change
 }
 
-func New(s *store.Store, ext extract.Extractor, r *rules.Rules, st *stats.Stats, p *patterns.Patterns) *CLI {
+func New(s store.Store, ext extract.Extractor, r *rules.Rules, st *stats.Stats, p *patterns.Patterns) *CLI {
 	return &CLI{
 		store:    s,
 		extractor: ext,
@@ -31,12 +38,31 @@ func New(s *store.Store, ext extract.Extractor, r *rules.Rules, st *stats.Stats,
 	}
 }
 
+// SetAt sets when the input being recorded happened, as in --at "yesterday
+// 4pm". It overrides any time the input itself gives.
+func (c *CLI) SetAt(expr string) error {
+	at, hasClock, err := dates.ResolvePastDateTime(expr, c.store.Now())
+	if err != nil {
+		return fmt.Errorf("invalid --at: %w", err)
+	}
+	c.at = at.Format("2006-01-02")
+	if hasClock {
+		c.at = at.Format("2006-01-02 15:04")
+	}
+	c.atPhrase = expr
+	return nil
+}
+
 // HandleInput processes natural language input
-func (c *CLI) HandleInput(input string) error {
+func (c *CLI) HandleInput(input string) (err error) {
 	// Create entry
+	id, err := c.store.NewID(store.KindEntry)
+	if err != nil {
+		return err
+	}
 	entry := &core.Entry{
-		ID:        generateID(),
-		Timestamp: time.Now(),
+		ID:        id,
+		Timestamp: c.store.Now(),
 		Raw:       input,
 	}
 
@@ -46,7 +72,7 @@ func (c *CLI) HandleInput(input string) error {
 	s.Start()
 
 	// Extract candidates
-	candidates, questions, err := c.extractor.Extract(input)
+	candidates, _, err := c.extractor.Extract(input, c.store.Now())
 	
 	s.Stop()
 	
@@ -54,44 +80,291 @@ func (c *CLI) HandleInput(input string) error {
 		return fmt.Errorf("extraction failed: %w", err)
 	}
 
-	// If we have blocking questions from extraction, ask them
-	if len(questions) > 0 {
-		return c.handleQuestions(questions, entry)
+	if c.at != "" {
+		c.applyAt(candidates)
 	}
 
-	// Process each candidate
-	for _, candidate := range candidates {
-		if err := c.processCandidate(candidate, entry); err != nil {
-			return err
+	// Keep the input and what was made of it, so anything recorded from it
+	// can be traced back through its SourceEntry
+	entry.Candidates = candidates
+	entry.Source = c.extractor.Name()
+	entry.Confidence = lowestConfidence(candidates)
+	if c.mode != WriteDryRun {
+		// The entry goes with what's recorded from it, so undo takes it back
+		// too; input that records nothing is still logged
+		c.unsaved = entry
+		defer func() {
+			if c.unsaved != nil {
+				if saveErr := c.saveUnsaved(); saveErr != nil && err == nil {
+					err = saveErr
+				}
+			}
+		}()
+	}
+
+	if len(candidates) == 1 {
+		_, err := c.holdUnanswered(c.processCandidate(candidates[0], entry), entry)
+		if errors.Is(err, errSkipped) {
+			return nil
+		}
+		return err
+	}
+
+	// Process each candidate on its own so one bad part doesn't sink the rest
+	recorded, held, skipped := 0, 0, 0
+	for i, candidate := range candidates {
+		fmt.Printf("[%d/%d] %s\n", i+1, len(candidates), candidate.Text)
+		wasHeld, err := c.holdUnanswered(c.processCandidate(candidate, candidateEntry(candidate, entry)), entry)
+		if errors.Is(err, errSkipped) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			fmt.Printf("  not recorded: %v\n", err)
+			continue
+		}
+		if wasHeld {
+			held++
+			continue
 		}
+		recorded++
+	}
+
+	fmt.Printf("recorded %d of %d", recorded, len(candidates))
+	if held > 0 {
+		fmt.Printf(", %d waiting in the inbox", held)
+	}
+	if skipped > 0 {
+		fmt.Printf(", %d skipped", skipped)
+	}
+	fmt.Println()
+	if failed := len(candidates) - recorded - held - skipped; failed > 0 {
+		return fmt.Errorf("%d of %d items not recorded", failed, len(candidates))
 	}
 
 	return nil
 }
 
-func (c *CLI) processCandidate(candidate core.Candidate, entry *core.Entry) error {
-	// Validate with rules
-	result, err := c.rules.Validate(candidate, entry)
-	if err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+func lowestConfidence(candidates []core.Candidate) float64 {
+	lowest := 0.0
+	for i, candidate := range candidates {
+		if i == 0 || candidate.Confidence < lowest {
+			lowest = candidate.Confidence
+		}
+	}
+	return lowest
+}
+
+// candidateEntry narrows the entry to the part of the input a candidate came
+// from, so multi-intent input doesn't log the whole line once per candidate
+func candidateEntry(candidate core.Candidate, entry *core.Entry) *core.Entry {
+	if candidate.Text == "" {
+		return entry
 	}
+	narrowed := *entry
+	narrowed.Raw = candidate.Text
+	return &narrowed
+}
+
+// record runs fn as one operation undo can take back, saving the input it
+// came from first if that isn't saved yet
+func (c *CLI) record(label string, fn func() error) error {
+	return c.store.Record(label, func() error {
+		if err := c.saveUnsaved(); err != nil {
+			return err
+		}
+		return fn()
+	})
+}
+
+// saveUnsaved saves the input, under another ID if a concurrent grechen
+// took the one it was given. What was validated from it is pointed at the
+// new ID when it's recorded.
+func (c *CLI) saveUnsaved() error {
+	if c.unsaved == nil {
+		return nil
+	}
+	return c.store.Update(func() error {
+		id, err := c.store.ClaimID(store.KindEntry, c.unsaved.ID)
+		if err != nil {
+			return err
+		}
+		if id != c.unsaved.ID {
+			c.entryIDs = map[string]string{c.unsaved.ID: id}
+			c.unsaved.ID = id
+		}
+		if err := c.store.SaveEntry(c.unsaved); err != nil {
+			return fmt.Errorf("failed to save entry: %w", err)
+		}
+		c.unsaved = nil
+		return nil
+	})
+}
 
-	// If we have blocking questions, ask them
-	if !result.Valid {
-		return c.handleQuestions(result.Questions, entry)
+// savedEntryID is the ID the input given id was saved under
+func (c *CLI) savedEntryID(id string) string {
+	if saved, ok := c.entryIDs[id]; ok {
+		return saved
 	}
+	return id
+}
 
-	// Execute action
-	return c.executeAction(result.Action, candidate, entry)
+// claimIDs makes sure the IDs validation picked for what action creates are
+// still unused now the store lock is held, and points what it creates at
+// the input as saved. Callers hold the lock.
+func (c *CLI) claimIDs(action *rules.Action) error {
+	var err error
+	claim := func(kind string, id, source *string) {
+		if err == nil {
+			*id, err = c.store.ClaimID(kind, *id)
+		}
+		*source = c.savedEntryID(*source)
+	}
+	if commitment := action.Commitment; commitment != nil {
+		claim(store.KindCommitment, &commitment.ID, &commitment.SourceEntry)
+		for i := range commitment.History {
+			commitment.History[i].EntryID = c.savedEntryID(commitment.History[i].EntryID)
+		}
+	}
+	if event := action.Event; event != nil {
+		claim(store.KindCalendar, &event.ID, &event.SourceEntry)
+	}
+	if progress := action.Progress; progress != nil {
+		claim(store.KindProgress, &progress.ID, &progress.SourceEntry)
+	}
+	return err
 }
 
+// applyAt sets --at as when the candidates that can be backdated happened,
+// dropping any question about it. Their dates are resolved again from --at,
+// so "--at yesterday" with "the report by tomorrow" is due today.
+func (c *CLI) applyAt(candidates []core.Candidate) {
+	for i := range candidates {
+		candidate := &candidates[i]
+		if !extract.Backdatable(candidate.Type) {
+			continue
+		}
+		if candidate.Data == nil {
+			candidate.Data = make(map[string]any)
+		}
+		extract.Unresolve(candidate)
+		candidate.Data["occurred_at"] = c.atPhrase
+
+		var questions []core.Question
+		for _, q := range candidate.Questions {
+			if q.Field != "occurred_at" {
+				questions = append(questions, q)
+			}
+		}
+		candidate.Questions = questions
+		extract.ResolveDates(candidate, c.store.Now())
+	}
+}
+
+// occurredEntry is the entry as of when what the candidate describes
+// happened, when the input says. A date without a time of day keeps the
+// entry's.
+func (c *CLI) occurredEntry(candidate core.Candidate, entry *core.Entry) *core.Entry {
+	value, _ := candidate.Data["occurred_at"].(string)
+	if value == "" || !extract.Backdatable(candidate.Type) {
+		return entry
+	}
+
+	loc := c.store.Location()
+	at, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+	if err != nil {
+		day, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return entry
+		}
+		typed := entry.Timestamp.In(loc)
+		at = time.Date(day.Year(), day.Month(), day.Day(), typed.Hour(), typed.Minute(), typed.Second(), typed.Nanosecond(), loc)
+	}
+
+	backdated := *entry
+	backdated.Timestamp = at
+	return &backdated
+}
+
+func (c *CLI) processCandidate(candidate core.Candidate, entry *core.Entry) error {
+	return c.resolveCandidate(candidate, candidate.Questions, entry, nil)
+}
+
+// resolveCandidate asks the questions, then validates the candidate and
+// asks whatever validation wants to know, until it's valid and recorded or
+// the questions can't be answered now. then, if given, runs as part of the
+// same operation as the recording.
+func (c *CLI) resolveCandidate(candidate core.Candidate, questions []core.Question, entry *core.Entry, then func() error) error {
+	for {
+		if len(questions) > 0 {
+			var err error
+			if questions, err = c.handleQuestions(&candidate, questions); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Validate with rules, as of when it happened
+		written := c.occurredEntry(candidate, entry)
+		result, err := c.rules.Validate(candidate, written)
+		if err != nil {
+			return fmt.Errorf("validation failed: %w", err)
+		}
+
+		// If we have blocking questions, ask them
+		if !result.Valid {
+			questions = result.Questions
+			continue
+		}
+
+		if err := c.confirmAction(result.Action, candidate); err != nil {
+			return err
+		}
+
+		// Execute action as one operation undo can take back, holding the
+		// store lock so the read-modify-write of an update can't interleave
+		// with another grechen process
+		return c.record(entry.Raw, func() error {
+			if err := c.executeAction(result.Action, candidate, written); err != nil {
+				return err
+			}
+			if then != nil {
+				return then()
+			}
+			return nil
+		})
+	}
+}
+
+// executeAction records a validated action. Its lines go in the daily file
+// of the entry's day, which is earlier than today for backdated input.
 func (c *CLI) executeAction(action rules.Action, candidate core.Candidate, entry *core.Entry) error {
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	now := c.store.Now()
+	day := c.store.Day(entry.Timestamp)
+
+	if id := c.savedEntryID(entry.ID); id != entry.ID {
+		saved := *entry
+		saved.ID = id
+		entry = &saved
+	}
+	if err := c.claimIDs(&action); err != nil {
+		return err
+	}
+
+	for _, person := range action.NewPeople {
+		if err := c.store.SavePerson(person); err != nil {
+			return fmt.Errorf("failed to save person: %w", err)
+		}
+	}
+	for _, project := range action.NewProjects {
+		if err := c.store.SaveProject(project); err != nil {
+			return fmt.Errorf("failed to save project: %w", err)
+		}
+	}
 
 	switch action.Type {
 	case core.IntentLog:
-		if err := c.store.AppendLog(today, entry); err != nil {
+		if err := c.store.AppendLog(day, entry); err != nil {
 			return fmt.Errorf("failed to append log: %w", err)
 		}
 		fmt.Printf("logged as: %s (confidence: %.2f)\n", candidate.Type, candidate.Confidence)
@@ -100,7 +373,7 @@ func (c *CLI) executeAction(action rules.Action, candidate core.Candidate, entry
 		if err := c.store.SaveCommitment(action.Commitment); err != nil {
 			return fmt.Errorf("failed to save commitment: %w", err)
 		}
-		if err := c.store.AppendCommitment(today, action.Commitment); err != nil {
+		if err := c.store.AppendCommitment(day, action.Commitment); err != nil {
 			return fmt.Errorf("failed to append commitment: %w", err)
 		}
 		fmt.Printf("logged commitment to %s: %s (due %s, confidence: %.2f)\n",
@@ -115,58 +388,71 @@ func (c *CLI) executeAction(action rules.Action, candidate core.Candidate, entry
 			return fmt.Errorf("commitment not found: %w", err)
 		}
 
-		// Update commitment
-		commitment.Status = action.Update.Status
-		now := time.Now()
-		commitment.LastUpdateAt = &now
-		commitment.History = append(commitment.History, core.CommitmentEvent{
-			Timestamp:   now,
-			Type:        string(action.Update.Status),
-			Description: action.Update.Description,
-		})
+		// Update commitment; rechecked here since it may have changed since
+		// validation
+		if err := rules.Transition(commitment, action.Update.Status, now, entry.ID, action.Update.Description); err != nil {
+			return err
+		}
 
 		if err := c.store.SaveCommitment(commitment); err != nil {
 			return fmt.Errorf("failed to update commitment: %w", err)
 		}
-		if err := c.store.AppendCommitment(today, commitment); err != nil {
+		if err := c.store.AppendCommitment(day, commitment); err != nil {
 			return fmt.Errorf("failed to append commitment update: %w", err)
 		}
 		fmt.Printf("updated commitment: %s\n", commitment.Expectation.Description)
 
+	case core.IntentRenegotiation:
+		commitment, err := c.store.GetCommitment(action.Renegotiation.CommitmentID)
+		if err != nil {
+			return fmt.Errorf("commitment not found: %w", err)
+		}
+		if err := c.renegotiate(commitment, action.Renegotiation.Deadline, entry.ID, entry.Raw); err != nil {
+			return err
+		}
+
 	case core.IntentProgress:
-		if err := c.store.AppendLog(today, entry); err != nil {
+		if err := c.store.SaveProgress(action.Progress); err != nil {
+			return fmt.Errorf("failed to save progress: %w", err)
+		}
+		if err := c.store.AppendLog(day, entry); err != nil {
 			return fmt.Errorf("failed to append progress: %w", err)
 		}
 		fmt.Printf("logged progress on %s (confidence: %.2f)\n", action.Progress.ProjectID, candidate.Confidence)
 
 	case core.IntentEvent:
-		// For now, just log the event
-		if err := c.store.AppendLog(today, entry); err != nil {
+		if err := c.store.SaveEvent(action.Event); err != nil {
+			return fmt.Errorf("failed to save event: %w", err)
+		}
+		if err := c.store.AppendLog(day, entry); err != nil {
 			return fmt.Errorf("failed to append event: %w", err)
 		}
-		fmt.Printf("logged event: %s (confidence: %.2f)\n", action.Event.Title, candidate.Confidence)
+		fmt.Printf("logged event: %s (%s, confidence: %.2f)\n", action.Event.Title, c.eventWhen(action.Event), candidate.Confidence)
 
 	case core.IntentCorrection:
-		if err := c.store.AppendNote(today, fmt.Sprintf("correction: %s", entry.Raw)); err != nil {
-			return fmt.Errorf("failed to append correction: %w", err)
-		}
-		fmt.Printf("correction logged (confidence: %.2f)\n", candidate.Confidence)
+		return c.correctLast(entry.Raw, entry)
 
 	default:
 		return fmt.Errorf("unknown action type: %s", action.Type)
 	}
 
+	if extract.Backdatable(action.Type) && now.Sub(entry.Timestamp) > time.Minute {
+		fmt.Printf("  at %s\n", entry.Timestamp.In(c.store.Location()).Format("2006-01-02 15:04"))
+	}
 	return nil
 }
 
-func (c *CLI) handleQuestions(questions []core.Question, entry *core.Entry) error {
-	// For now, just print questions (future: interactive Charm forms)
-	for _, q := range questions {
-		fmt.Printf("? %s\n", q.Text)
+// handleQuestions asks the questions on the terminal and fills the answers
+// into the candidate, returning any follow-up questions. Without a terminal,
+// or when the user gives up, it returns an *unansweredError.
+func (c *CLI) handleQuestions(candidate *core.Candidate, questions []core.Question) ([]core.Question, error) {
+	if !interactive() {
+		return nil, &unansweredError{candidate: *candidate, questions: questions}
 	}
-	return fmt.Errorf("questions need answers")
-}
 
-func generateID() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+	followUps, err := c.answerQuestions(candidate, questions)
+	if errors.Is(err, errCancelled) {
+		return nil, &unansweredError{candidate: *candidate, questions: questions}
+	}
+	return followUps, err
 }
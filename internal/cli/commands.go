@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"strconv"
@@ -9,12 +10,15 @@ import (
 	"time"
 
 	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/dates"
+	"github.com/heywinit/grechen/internal/stats"
+	"github.com/heywinit/grechen/internal/store"
 )
 
 // HandleToday shows situational awareness for today
 func (c *CLI) HandleToday() error {
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	now := c.store.Now()
+	today := c.store.Day(now)
 
 	// Get today's stats
 	stats, err := c.stats.ComputeDailyStats(today)
@@ -31,11 +35,21 @@ func (c *CLI) HandleToday() error {
 	fmt.Printf("  commitment updates: %d\n", stats.CommitmentUpdates)
 
 	// Show today's logs
-	content, err := c.store.ReadDailyFile(today)
+	entries, err := c.store.ListDailyEntries(today)
 	if err != nil {
 		return err
 	}
-	logs := extractLogs(content)
+	var logs []string
+	for _, entry := range entries {
+		if entry.Section != store.SectionLogs {
+			continue
+		}
+		if entry.Time != nil {
+			logs = append(logs, entry.Time.Format("15:04")+" "+entry.Text)
+		} else {
+			logs = append(logs, entry.Text)
+		}
+	}
 	if len(logs) > 0 {
 		fmt.Println("\nlogs:")
 		for _, log := range logs {
@@ -43,6 +57,18 @@ func (c *CLI) HandleToday() error {
 		}
 	}
 
+	// Show what's coming up: the rest of today and the next few days
+	events, err := c.store.ListEventsBetween(now, today.AddDate(0, 0, agendaDays))
+	if err != nil {
+		return err
+	}
+	if len(events) > 0 {
+		fmt.Println("\nupcoming events:")
+		for _, event := range events {
+			c.printEvent(event, true)
+		}
+	}
+
 	// Show open commitments
 	commitments, err := c.store.ListOpenCommitments()
 	if err != nil {
@@ -61,55 +87,48 @@ func (c *CLI) HandleToday() error {
 		}
 	}
 
+	c.mentionInbox()
 	return nil
 }
 
-// extractLogs extracts log entries from daily file content
-func extractLogs(content string) []string {
-	lines := strings.Split(content, "\n")
-	inLogsSection := false
-	var logs []string
+// HandleCommitments shows all commitments
+//
+//	grechen commitments [--open] [--at DATE]
+//
+// --at shows them as they stood at the end of DATE ("march 3", "yesterday",
+// 2024-03-03), replayed from the commitment journal.
+func (c *CLI) HandleCommitments(args []string) error {
+	fs := flag.NewFlagSet("commitments", flag.ContinueOnError)
+	openOnly := fs.Bool("open", false, "only show open commitments")
+	at := fs.String("at", "", "show commitments as they stood at the end of this day")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
 
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "## logs" {
-			inLogsSection = true
-			continue
+	var commitments []*core.Commitment
+	var err error
+	if *at != "" {
+		day, err := dates.ResolvePast(*at, c.store.Now())
+		if err != nil {
+			return fmt.Errorf("invalid --at date: %w", err)
 		}
-		if strings.HasPrefix(trimmed, "## ") {
-			inLogsSection = false
-			continue
-		}
-		if inLogsSection && strings.HasPrefix(trimmed, "- ") {
-			// Format: "- HHMM text" -> display as "HH:MM text"
-			if len(trimmed) > 2 {
-				logText := trimmed[2:] // Remove "- "
-				// Try to format time if it starts with 4 digits (HHMM format)
-				if len(logText) >= 4 {
-					timePart := logText[:4]
-					rest := logText[4:]
-					// Check if first 4 chars are digits
-					if len(timePart) == 4 && strings.Trim(timePart, "0123456789") == "" {
-						// Format as HH:MM
-						formatted := timePart[:2] + ":" + timePart[2:]
-						logs = append(logs, formatted+" "+strings.TrimSpace(rest))
-						continue
-					}
-				}
-				// If no time format detected, just show the log as-is
-				logs = append(logs, strings.TrimSpace(logText))
-			}
+		commitments, err = c.store.ListCommitmentsAt(day.AddDate(0, 0, 1).Add(-time.Nanosecond))
+		if err != nil {
+			return err
 		}
+		fmt.Printf("as of %s:\n", day.Format("2006-01-02"))
+	} else if commitments, err = c.store.ListCommitments(); err != nil {
+		return err
 	}
 
-	return logs
-}
-
-// HandleCommitments shows all commitments
-func (c *CLI) HandleCommitments() error {
-	commitments, err := c.store.ListCommitments()
-	if err != nil {
-		return err
+	if *openOnly {
+		var open []*core.Commitment
+		for _, c := range commitments {
+			if c.Status == core.StatusOpen || c.Status == core.StatusUpdated {
+				open = append(open, c)
+			}
+		}
+		commitments = open
 	}
 
 	if len(commitments) == 0 {
@@ -117,10 +136,11 @@ func (c *CLI) HandleCommitments() error {
 		return nil
 	}
 
+	aliases := c.shortIDs(store.KindCommitment)
 	fmt.Println("commitments:")
 	for _, c := range commitments {
 		fmt.Printf("  [%s] %s → %s (due %s, status: %s)\n",
-			c.ID,
+			shortID(aliases, c.ID),
 			c.PersonID,
 			c.Expectation.Description,
 			c.Expectation.Deadline.Format("2006-01-02"),
@@ -132,8 +152,7 @@ func (c *CLI) HandleCommitments() error {
 
 // HandleReview shows stats summary and pattern alerts
 func (c *CLI) HandleReview() error {
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	today := c.store.Today()
 
 	// Get rolling stats (last 7 days)
 	rollingStats, err := c.stats.ComputeRollingStats(today, 7)
@@ -149,6 +168,13 @@ func (c *CLI) HandleReview() error {
 	fmt.Printf("  avg progress entries/day: %.1f\n", rollingStats.AvgProgressEntries)
 	fmt.Printf("  avg commitment updates/day: %.1f\n", rollingStats.AvgCommitmentUpdates)
 
+	byPerson, byProject, err := c.stats.ComputeSlipStats()
+	if err != nil {
+		return err
+	}
+	printSlips("slips by person:", byPerson)
+	printSlips("slips by project:", byProject)
+
 	// Check patterns
 	deviations, err := c.patterns.Evaluate(today, rollingStats)
 	if err != nil {
@@ -165,18 +191,21 @@ func (c *CLI) HandleReview() error {
 	return nil
 }
 
-// HandleThatsWrong handles correction flow
-func (c *CLI) HandleThatsWrong() error {
-	fmt.Println("what was wrong?")
-	// In future: read from stdin or use Charm form
-	// For now, this is a placeholder
-	return fmt.Errorf("correction flow not fully implemented")
+func printSlips(title string, slips []stats.SlipStats) {
+	if len(slips) == 0 {
+		return
+	}
+	fmt.Println("\n" + title)
+	for _, s := range slips {
+		fmt.Printf("  %s: %d of %d commitments slipped, %d slips, %d days late in total\n",
+			s.Key, s.Slipped, s.Commitments, s.Slips, s.DaysSlipped)
+	}
 }
 
 // HandleTodo shows all remaining todos from previous days
 func (c *CLI) HandleTodo() error {
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	now := c.store.Now()
+	today := c.store.Day(now)
 
 	commitments, err := c.store.ListOpenCommitmentsFromPreviousDays(today)
 	if err != nil {
@@ -222,13 +251,7 @@ func (c *CLI) HandleProjects() error {
 
 	fmt.Println("projects:")
 	for i, p := range projects {
-		priority := "normal"
-		if p.Priority > 0 {
-			priority = "high"
-		} else if p.Priority < 0 {
-			priority = "low"
-		}
-		fmt.Printf("  %d. %s (priority: %s)\n", i+1, p.ID, priority)
+		fmt.Printf("  %d. %s (priority: %s)\n", i+1, p.ID, priorityName(p.Priority))
 		if len(p.Metadata) > 0 {
 			fmt.Printf("     metadata: %v\n", p.Metadata)
 		}
@@ -279,7 +302,7 @@ func (c *CLI) editProject(project *core.Project, reader *bufio.Reader) error {
 	}
 
 	// Save
-	if err := c.store.SaveProject(project); err != nil {
+	if err := c.store.Record("edit project "+project.ID, func() error { return c.store.SaveProject(project) }); err != nil {
 		return fmt.Errorf("failed to save project: %w", err)
 	}
 
@@ -347,7 +370,7 @@ func (c *CLI) editPerson(person *core.Person, reader *bufio.Reader) error {
 	}
 
 	// Save
-	if err := c.store.SavePerson(person); err != nil {
+	if err := c.store.Record("edit person "+person.ID, func() error { return c.store.SavePerson(person) }); err != nil {
 		return fmt.Errorf("failed to save person: %w", err)
 	}
 
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/extract"
+	"github.com/heywinit/grechen/internal/rules"
+	"github.com/heywinit/grechen/internal/store"
+)
+
+// WriteMode says what happens to an action once an input has been made
+// sense of
+type WriteMode int
+
+const (
+	WriteDirect  WriteMode = iota // record it
+	WriteConfirm                  // show it and record it once confirmed
+	WriteDryRun                   // show it and record nothing
+)
+
+// errSkipped is returned for an action that was shown but not recorded: a
+// dry run, or one that wasn't confirmed
+var errSkipped = errors.New("not recorded")
+
+// SetWriteMode sets whether natural-language input is recorded right away,
+// after confirmation, or not at all
+func (c *CLI) SetWriteMode(mode WriteMode) {
+	c.mode = mode
+}
+
+// confirmAction shows what's about to be recorded, unless it's recorded
+// right away, and returns errSkipped if it shouldn't be
+func (c *CLI) confirmAction(action rules.Action, candidate core.Candidate) error {
+	if c.mode == WriteDirect {
+		return nil
+	}
+
+	c.printAction(action, candidate)
+	if c.mode == WriteDryRun {
+		fmt.Println("dry run, not recorded")
+		return errSkipped
+	}
+
+	if !interactive() {
+		return fmt.Errorf("can't confirm without a terminal, run with --yes to record without asking")
+	}
+	fmt.Print("? record this? [y/N] ")
+	line, err := stdin.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read answer: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return nil
+	}
+	fmt.Println("not recorded")
+	return errSkipped
+}
+
+// printAction renders a validated action: its intent and confidence, and
+// the person, project, deadline and status change it comes down to
+func (c *CLI) printAction(action rules.Action, candidate core.Candidate) {
+	fmt.Printf("%s (confidence: %.2f)\n", action.Type, candidate.Confidence)
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Printf("  %s: %s\n", name, value)
+		}
+	}
+
+	switch action.Type {
+	case core.IntentCommitment:
+		commitment := action.Commitment
+		field("person", commitment.PersonID)
+		field("project", commitment.ProjectID)
+		field("expectation", commitment.Expectation.Description)
+		field("deadline", fmt.Sprintf("%s (%s)", commitment.Expectation.Deadline.Format("2006-01-02"), commitment.Expectation.Hardness))
+		field("status", "new → "+string(commitment.Status))
+
+	case core.IntentUpdate:
+		c.printTarget(action.Update.CommitmentID, field)
+		if commitment, err := c.store.GetCommitment(action.Update.CommitmentID); err == nil {
+			field("status", fmt.Sprintf("%s → %s", commitment.Status, action.Update.Status))
+		}
+		field("note", action.Update.Description)
+
+	case core.IntentRenegotiation:
+		c.printTarget(action.Renegotiation.CommitmentID, field)
+		deadline := action.Renegotiation.Deadline.Format("2006-01-02")
+		if commitment, err := c.store.GetCommitment(action.Renegotiation.CommitmentID); err == nil {
+			deadline = fmt.Sprintf("%s → %s", commitment.Expectation.Deadline.Format("2006-01-02"), deadline)
+			if commitment.Status == core.StatusViolated {
+				field("status", fmt.Sprintf("%s → %s", commitment.Status, core.StatusOpen))
+			}
+		}
+		field("deadline", deadline)
+
+	case core.IntentProgress:
+		field("project", action.Progress.ProjectID)
+		field("status", action.Progress.Status)
+		field("notes", action.Progress.Notes)
+		field("log", action.Entry.Raw)
+
+	case core.IntentEvent:
+		field("title", action.Event.Title)
+		field("when", c.eventWhen(action.Event))
+		field("with", strings.Join(action.Event.Attendees, ", "))
+		field("project", action.Event.ProjectID)
+
+	case core.IntentCorrection:
+		field("corrects", "the last entry")
+		field("correction", action.Entry.Raw)
+
+	default:
+		field("log", action.Entry.Raw)
+	}
+
+	if _, ok := candidate.Data["occurred_at"]; ok && extract.Backdatable(action.Type) {
+		field("at", action.Entry.Timestamp.In(c.store.Location()).Format("2006-01-02 15:04"))
+	}
+	for _, person := range action.NewPeople {
+		field("new person", person.ID)
+	}
+	for _, project := range action.NewProjects {
+		field("new project", project.ID)
+	}
+}
+
+// printTarget names the existing commitment an action changes
+func (c *CLI) printTarget(id string, field func(name, value string)) {
+	field("commitment", shortID(c.shortIDs(store.KindCommitment), id))
+	if commitment, err := c.store.GetCommitment(id); err == nil {
+		field("person", commitment.PersonID)
+		field("project", commitment.ProjectID)
+		field("expectation", commitment.Expectation.Description)
+	}
+}
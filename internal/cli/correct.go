@@ -0,0 +1,767 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/dates"
+	"github.com/heywinit/grechen/internal/rules"
+	"github.com/heywinit/grechen/internal/store"
+)
+
+// correctionSource is the Source of entries made by grechen thats-wrong
+const correctionSource = "thats-wrong"
+
+// HandleThatsWrong corrects what grechen made of the last entry
+//
+//	grechen thats-wrong [what was wrong]
+//
+// It shows the last entry and what it led to, then takes the correction from
+// the arguments, or asks for it. A correction is either fields
+// ("person=bob deadline=friday") or words: "it was bob, not alice", "not a
+// commitment", "undo", or what the entry should have said, which replaces
+// it. Amended commitments keep the correction in their history.
+func (c *CLI) HandleThatsWrong(args []string) error {
+	fx, err := c.lastEffects()
+	if err != nil {
+		return err
+	}
+	c.printEffects(fx)
+
+	text, err := c.correctionText(strings.Join(args, " "))
+	if errors.Is(err, errCancelled) {
+		fmt.Println("nothing changed")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	id, err := c.store.NewID(store.KindEntry)
+	if err != nil {
+		return err
+	}
+	entry := &core.Entry{
+		ID:        id,
+		Timestamp: c.store.Now(),
+		Raw:       text,
+		Candidates: []core.Candidate{{
+			Type:       core.IntentCorrection,
+			Confidence: 1,
+			Text:       text,
+			Data:       map[string]any{"text": text, "entry_id": fx.entry.ID},
+		}},
+		Source:     correctionSource,
+		Confidence: 1,
+	}
+	return c.store.Record("grechen thats-wrong "+text, func() error {
+		if entry.ID, err = c.store.ClaimID(store.KindEntry, entry.ID); err != nil {
+			return err
+		}
+		if err := c.store.SaveEntry(entry); err != nil {
+			return fmt.Errorf("failed to save entry: %w", err)
+		}
+		return c.applyCorrection(fx, text, entry)
+	})
+}
+
+// correctLast corrects the last entry from a correction given as input
+// ("that's wrong, it was bob"), recorded as coming from entry
+func (c *CLI) correctLast(text string, entry *core.Entry) error {
+	fx, err := c.lastEffects()
+	if err != nil {
+		return err
+	}
+	c.printEffects(fx)
+
+	text, err = c.correctionText(text)
+	if errors.Is(err, errCancelled) {
+		fmt.Println("nothing changed")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return c.applyCorrection(fx, text, entry)
+}
+
+// correctionText is the correction without a leading "that's wrong", asked
+// for when there's nothing else
+func (c *CLI) correctionText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	for {
+		stripped := strings.TrimSpace(correctionLead.ReplaceAllString(text, ""))
+		if stripped == text {
+			break
+		}
+		text = stripped
+	}
+	if text != "" {
+		return text, nil
+	}
+
+	if !interactive() {
+		return "", fmt.Errorf(`say what was wrong, e.g. grechen thats-wrong "it was bob, not alice"`)
+	}
+	answers, err := c.HandleInteractive([]core.Question{{
+		ID:       "correction",
+		Text:     "what was wrong?",
+		Required: true,
+		Field:    "correction",
+	}})
+	if err != nil {
+		return "", err
+	}
+	return answers["correction"], nil
+}
+
+// effects is what processing an entry left behind
+type effects struct {
+	entry    *core.Entry
+	logs     []*core.Entry // log lines, as the entries they were written from
+	created  []*core.Commitment
+	changed  []changedCommitment
+	events   []*core.Event
+	progress []*core.Progress
+	pending  []*core.PendingItem
+}
+
+// changedCommitment is an existing commitment an entry changed, before and
+// after
+type changedCommitment struct {
+	before, after *core.Commitment
+}
+
+func (fx *effects) empty() bool {
+	return len(fx.logs) == 0 && len(fx.created) == 0 && len(fx.changed) == 0 && len(fx.events) == 0 &&
+		len(fx.progress) == 0 && len(fx.pending) == 0
+}
+
+// lastEffects finds the last entry that wasn't itself a correction and what
+// it led to
+func (c *CLI) lastEffects() (*effects, error) {
+	entries, err := c.store.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !isCorrection(entries[i]) {
+			return c.findEffects(entries[i])
+		}
+	}
+	return nil, fmt.Errorf("nothing to correct yet")
+}
+
+func isCorrection(entry *core.Entry) bool {
+	if entry.Source == correctionSource {
+		return true
+	}
+	for _, candidate := range entry.Candidates {
+		if candidate.Type != core.IntentCorrection {
+			return false
+		}
+	}
+	return len(entry.Candidates) > 0
+}
+
+func (c *CLI) findEffects(entry *core.Entry) (*effects, error) {
+	fx := &effects{entry: entry}
+
+	// Log lines are found by their HHMM and text in the day they were
+	// logged on; input split into several candidates logs each part on its
+	// own
+	for _, w := range c.writtenEntries(entry) {
+		lines, err := c.store.ListDailyEntries(w.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		if c.hasLogLine(lines, w) {
+			fx.logs = append(fx.logs, w)
+		}
+	}
+
+	// Commitments it created, and ones it changed along with how they stood
+	// before it
+	before, err := c.store.ListCommitmentsAt(entry.Timestamp.Add(-time.Nanosecond))
+	if err != nil {
+		return nil, err
+	}
+	previous := make(map[string]*core.Commitment, len(before))
+	for _, commitment := range before {
+		previous[commitment.ID] = commitment
+	}
+	commitments, err := c.store.ListCommitments()
+	if err != nil {
+		return nil, err
+	}
+	for _, commitment := range commitments {
+		if commitment.SourceEntry == entry.ID {
+			fx.created = append(fx.created, commitment)
+			continue
+		}
+		for _, event := range commitment.History {
+			if event.EntryID == entry.ID && previous[commitment.ID] != nil {
+				fx.changed = append(fx.changed, changedCommitment{before: previous[commitment.ID], after: commitment})
+				break
+			}
+		}
+	}
+
+	events, err := c.store.ListEvents()
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		if event.SourceEntry == entry.ID {
+			fx.events = append(fx.events, event)
+		}
+	}
+
+	progress, err := c.store.ListProgress()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range progress {
+		if p.SourceEntry == entry.ID {
+			fx.progress = append(fx.progress, p)
+		}
+	}
+
+	pending, err := c.store.ListPending()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range pending {
+		if item.EntryID == entry.ID {
+			fx.pending = append(fx.pending, item)
+		}
+	}
+	return fx, nil
+}
+
+// writtenEntries returns the entries an input's log lines were written
+// from: one per candidate when it was split, each at the time it happened
+func (c *CLI) writtenEntries(entry *core.Entry) []*core.Entry {
+	switch len(entry.Candidates) {
+	case 0:
+		return []*core.Entry{entry}
+	case 1:
+		return []*core.Entry{c.occurredEntry(entry.Candidates[0], entry)}
+	}
+	var written []*core.Entry
+	for _, candidate := range entry.Candidates {
+		written = append(written, c.occurredEntry(candidate, candidateEntry(candidate, entry)))
+	}
+	return written
+}
+
+// hasLogLine reports whether the day's lines have the log line written
+// from entry: its HHMM and text
+func (c *CLI) hasLogLine(lines []store.DailyEntry, entry *core.Entry) bool {
+	hhmm := entry.Timestamp.In(c.store.Location()).Format("1504")
+	for _, line := range lines {
+		if line.Section == store.SectionLogs && line.Time != nil && line.Time.Format("1504") == hhmm && line.Text == entry.Raw {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CLI) printEffects(fx *effects) {
+	aliases := c.shortIDs(store.KindCommitment)
+	fmt.Printf("last entry %s (%s): %s\n",
+		shortID(c.shortIDs(store.KindEntry), fx.entry.ID),
+		fx.entry.Timestamp.In(c.store.Location()).Format("2006-01-02 15:04"),
+		fx.entry.Raw)
+	for _, log := range fx.logs {
+		fmt.Printf("  logged: %s\n", log.Raw)
+	}
+	for _, commitment := range fx.created {
+		fmt.Printf("  commitment [%s] %s → %s (due %s, %s)\n",
+			shortID(aliases, commitment.ID),
+			commitment.PersonID,
+			commitment.Expectation.Description,
+			commitment.Expectation.Deadline.Format("2006-01-02"),
+			commitment.Status)
+	}
+	for _, change := range fx.changed {
+		description := describeChange(change.before, change.after)
+		if description == "" {
+			description = "since reverted"
+		}
+		fmt.Printf("  changed [%s] %s → %s: %s\n",
+			shortID(aliases, change.after.ID),
+			change.after.PersonID,
+			change.after.Expectation.Description,
+			description)
+	}
+	for _, event := range fx.events {
+		fmt.Printf("  event [%s] %s (%s)\n", event.ID, event.Title, c.eventWhen(event))
+	}
+	for _, p := range fx.progress {
+		fmt.Printf("  progress [%s] on %s: %s\n", p.ID, p.ProjectID, orNone(p.Status))
+	}
+	for _, item := range fx.pending {
+		fmt.Printf("  waiting in the inbox as %s\n", item.ID)
+	}
+	if fx.empty() {
+		fmt.Println("  nothing was recorded from it")
+	}
+	fmt.Println()
+}
+
+// describeChange lists the fields that differ between two states of a
+// commitment, e.g. "person alice → bob, due 2026-03-06 → 2026-03-09"
+func describeChange(before, after *core.Commitment) string {
+	var changes []string
+	add := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, fmt.Sprintf("%s %s → %s", field, orNone(from), orNone(to)))
+		}
+	}
+	add("status", string(before.Status), string(after.Status))
+	add("person", before.PersonID, after.PersonID)
+	add("project", before.ProjectID, after.ProjectID)
+	add("expectation", before.Expectation.Description, after.Expectation.Description)
+	add("due", before.Expectation.Deadline.Format("2006-01-02"), after.Expectation.Deadline.Format("2006-01-02"))
+	add("hardness", before.Expectation.Hardness, after.Expectation.Hardness)
+	return strings.Join(changes, ", ")
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// correction is what was wrong with an entry's result
+type correction struct {
+	revert bool              // undo everything the entry did
+	asLog  bool              // it was only a log: drop its commitments, keep the line
+	fields map[string]string // person, project, deadline, description, hardness, text
+	input  string            // what the entry should have said, processed in its place
+}
+
+var (
+	correctionLead = regexp.MustCompile(`(?i)^(?:that'?s wrong|that was wrong|wrong|correction|actually|no)\b[,:.!]?`)
+
+	correctionField = regexp.MustCompile(`(?i)\b(person|project|deadline|due|description|desc|hardness|text|intent)\s*=\s*`)
+	revertWords     = regexp.MustCompile(`^(?:revert|undo|ignore|delete|remove|drop|never ?mind|scratch|forget)(?:\s+(?:it|that|this|all of it))?[.!]*$`)
+	logWords        = regexp.MustCompile(`\b(?:not an? (?:commitment|promise|todo|task|event|update)|(?:just|only) (?:a )?(?:log|note)|was a log)\b`)
+	meantWords      = regexp.MustCompile(`(?:^|[,;.]\s*)(?:it was|it's|its|it should be|should be|should have been|i meant|meant|was)\s+(.+?)(?:,?\s+not\s+(.+?))?[.!]*$`)
+	notWords        = regexp.MustCompile(`^(\S+(?:\s+\S+)?),?\s+not\s+(.+?)[.!]*$`)
+	meantFiller     = regexp.MustCompile(`^(?:to|for|on|by|due|the)\s+`)
+)
+
+// parseCorrection reads a correction in field form ("person=bob due=friday")
+// or in words
+func (c *CLI) parseCorrection(text string) (*correction, error) {
+	lower := strings.ToLower(text)
+
+	if matches := correctionField.FindAllStringSubmatchIndex(text, -1); len(matches) > 0 {
+		corr := &correction{fields: make(map[string]string)}
+		for i, m := range matches {
+			end := len(text)
+			if i+1 < len(matches) {
+				end = matches[i+1][0]
+			}
+			key := strings.ToLower(text[m[2]:m[3]])
+			value := strings.Trim(strings.TrimSpace(text[m[1]:end]), `,;"'`)
+			switch key {
+			case "due":
+				key = "deadline"
+			case "desc":
+				key = "description"
+			}
+			if key == "intent" {
+				if strings.ToLower(value) != "log" {
+					return nil, fmt.Errorf("intent=%s can't be set directly, say what the entry should have said and it's processed again", value)
+				}
+				corr.asLog = true
+				continue
+			}
+			corr.fields[key] = value
+		}
+		return corr, nil
+	}
+
+	if revertWords.MatchString(lower) {
+		return &correction{revert: true}, nil
+	}
+	if logWords.MatchString(lower) {
+		return &correction{asLog: true}, nil
+	}
+
+	var meant, instead string
+	if m := meantWords.FindStringSubmatch(lower); m != nil {
+		meant, instead = m[1], m[2]
+	} else if m := notWords.FindStringSubmatch(lower); m != nil {
+		meant, instead = m[1], m[2]
+	} else if len(strings.Fields(lower)) <= 2 {
+		meant = lower
+	}
+	if meant != "" {
+		meant = trimFiller(meant)
+		field := c.classify(meant)
+		if name, ok := strings.CutSuffix(meant, " project"); ok {
+			meant, field = name, "project"
+		}
+		if field == "" && instead != "" {
+			// Unknown, but what it replaces says what kind of thing it is
+			field = c.classify(trimFiller(instead))
+		}
+		if field == "" {
+			return nil, fmt.Errorf("can't tell whether %q is a person, project or date, say person=%s or project=%s", meant, meant, meant)
+		}
+		return &correction{fields: map[string]string{field: meant}}, nil
+	}
+
+	// Anything longer is what the entry should have said
+	return &correction{revert: true, input: text}, nil
+}
+
+// trimFiller drops the "to", "for the" and the like a name or date is
+// given with
+func trimFiller(s string) string {
+	for {
+		trimmed := meantFiller.ReplaceAllString(s, "")
+		if trimmed == s {
+			return strings.TrimSpace(s)
+		}
+		s = trimmed
+	}
+}
+
+// classify names the field a word from a correction belongs to: a known
+// person, a known project or a date, or "" when it's none of these
+func (c *CLI) classify(s string) string {
+	if _, err := c.store.FindPersonByName(s); err == nil {
+		return "person"
+	}
+	if _, err := c.store.GetProject(s); err == nil {
+		return "project"
+	}
+	if _, err := dates.Resolve(s, c.store.Now()); err == nil {
+		return "deadline"
+	}
+	return ""
+}
+
+// applyCorrection makes the correction to what fx's entry left behind,
+// recording entry as the source of the change
+func (c *CLI) applyCorrection(fx *effects, text string, entry *core.Entry) error {
+	corr, err := c.parseCorrection(text)
+	if err != nil {
+		return err
+	}
+
+	now := c.store.Now()
+	day := c.store.Day(fx.entry.Timestamp)
+	aliases := c.shortIDs(store.KindCommitment)
+	changed := 0
+
+	if corr.revert || corr.asLog {
+		for _, commitment := range fx.created {
+			if commitment.Status == core.StatusArchived {
+				continue
+			}
+			old := *commitment
+			if err := rules.Transition(commitment, core.StatusArchived, now, entry.ID, "reverted: "+text); err != nil {
+				return err
+			}
+			if err := c.store.SaveCommitment(commitment); err != nil {
+				return fmt.Errorf("failed to save commitment: %w", err)
+			}
+			if err := c.store.ReplaceCommitment(c.lineDay(fx, &old), &old, nil); err != nil {
+				return fmt.Errorf("failed to remove commitment line: %w", err)
+			}
+			fmt.Printf("reverted commitment [%s] %s → %s\n", shortID(aliases, commitment.ID), commitment.PersonID, commitment.Expectation.Description)
+			changed++
+		}
+		for _, event := range fx.events {
+			if err := c.store.DeleteEvent(event.ID); err != nil {
+				return fmt.Errorf("failed to remove event: %w", err)
+			}
+			if err := c.dropUnreferenced(event.Attendees); err != nil {
+				return err
+			}
+			fmt.Printf("removed event [%s] %s\n", event.ID, event.Title)
+			changed++
+		}
+		for _, p := range fx.progress {
+			if err := c.store.DeleteProgress(p.ID); err != nil {
+				return fmt.Errorf("failed to remove progress: %w", err)
+			}
+			fmt.Printf("removed progress [%s] on %s\n", p.ID, p.ProjectID)
+			changed++
+		}
+	}
+
+	if corr.revert {
+		for _, change := range fx.changed {
+			commitment, before := change.after, change.before
+			description := describeChange(commitment, before)
+			if description == "" {
+				continue
+			}
+			old := *commitment
+			commitment.Status = before.Status
+			commitment.PersonID = before.PersonID
+			commitment.ProjectID = before.ProjectID
+			commitment.Expectation = before.Expectation
+			commitment.Slips = before.Slips
+			rules.Correct(commitment, now, entry.ID, fmt.Sprintf("reverted %s: %s", description, text))
+			if err := c.store.SaveCommitment(commitment); err != nil {
+				return fmt.Errorf("failed to save commitment: %w", err)
+			}
+			if err := c.store.ReplaceCommitment(day, &old, nil); err != nil {
+				return fmt.Errorf("failed to remove commitment line: %w", err)
+			}
+			fmt.Printf("restored [%s] %s → %s: %s\n", shortID(aliases, commitment.ID), commitment.PersonID, commitment.Expectation.Description, description)
+			changed++
+		}
+		for _, log := range fx.logs {
+			if err := c.store.ReplaceLog(c.store.Day(log.Timestamp), log, ""); err != nil {
+				return fmt.Errorf("failed to remove log: %w", err)
+			}
+			fmt.Printf("removed log: %s\n", log.Raw)
+			changed++
+		}
+	}
+
+	if corr.revert || corr.asLog {
+		for _, item := range fx.pending {
+			if err := c.store.DeletePending(item.ID); err != nil {
+				return err
+			}
+			fmt.Printf("dropped %s from the inbox\n", item.ID)
+			changed++
+		}
+	}
+
+	// What was taken for something else is kept as a plain log line
+	if corr.asLog && changed > 0 && len(fx.logs) == 0 {
+		logged := fx.entry
+		if len(fx.entry.Candidates) == 1 {
+			logged = c.occurredEntry(fx.entry.Candidates[0], fx.entry)
+		}
+		if err := c.store.AppendLog(c.store.Day(logged.Timestamp), logged); err != nil {
+			return fmt.Errorf("failed to append log: %w", err)
+		}
+		fmt.Printf("logged: %s\n", fx.entry.Raw)
+	}
+
+	if len(corr.fields) > 0 {
+		n, err := c.amend(fx, corr.fields, text, entry)
+		if err != nil {
+			return err
+		}
+		changed += n
+	}
+
+	if changed > 0 {
+		note := fmt.Sprintf("correction to %s: %s", shortID(c.shortIDs(store.KindEntry), fx.entry.ID), text)
+		if err := c.store.AppendNote(c.store.Day(now), note); err != nil {
+			return fmt.Errorf("failed to append correction: %w", err)
+		}
+	}
+
+	if corr.input != "" {
+		fmt.Printf("processing instead: %s\n", corr.input)
+		return c.HandleInput(corr.input)
+	}
+	if changed == 0 {
+		fmt.Println("nothing to change")
+	}
+	return nil
+}
+
+// amend sets fields on the commitments, progress and log lines fx's entry
+// left behind, returning how many it changed
+func (c *CLI) amend(fx *effects, fields map[string]string, text string, entry *core.Entry) (int, error) {
+	changed := 0
+
+	if raw, ok := fields["text"]; ok {
+		if len(fx.logs) == 0 {
+			return 0, fmt.Errorf("the last entry didn't log anything to change")
+		}
+		for _, log := range fx.logs {
+			if err := c.store.ReplaceLog(c.store.Day(log.Timestamp), log, raw); err != nil {
+				return 0, fmt.Errorf("failed to amend log: %w", err)
+			}
+			fmt.Printf("amended log: %s → %s\n", log.Raw, raw)
+			changed++
+		}
+	}
+
+	if value, ok := fields["project"]; ok && len(fx.progress) > 0 {
+		projectID, err := c.ensureProject(value)
+		if err != nil {
+			return 0, err
+		}
+		for _, p := range fx.progress {
+			if p.ProjectID == projectID {
+				continue
+			}
+			old := p.ProjectID
+			p.ProjectID = projectID
+			if err := c.store.SaveProgress(p); err != nil {
+				return 0, fmt.Errorf("failed to save progress: %w", err)
+			}
+			fmt.Printf("corrected progress [%s]: project %s → %s\n", p.ID, old, projectID)
+			changed++
+		}
+	}
+
+	var commitments []*core.Commitment
+	for _, commitment := range fx.created {
+		if commitment.Status != core.StatusArchived {
+			commitments = append(commitments, commitment)
+		}
+	}
+	for _, change := range fx.changed {
+		commitments = append(commitments, change.after)
+	}
+	// Text is only on log lines, and a project may have been on progress
+	needsCommitment := false
+	for field := range fields {
+		if field != "text" && (field != "project" || len(fx.progress) == 0) {
+			needsCommitment = true
+		}
+	}
+	if !needsCommitment {
+		return changed, nil
+	}
+	if len(commitments) == 0 {
+		return 0, fmt.Errorf("the last entry didn't record a commitment to change")
+	}
+
+	aliases := c.shortIDs(store.KindCommitment)
+	for _, commitment := range commitments {
+		old := *commitment
+		for field, value := range fields {
+			if err := c.setCommitmentField(commitment, field, value, fx.entry.Timestamp); err != nil {
+				return 0, err
+			}
+		}
+
+		description := describeChange(&old, commitment)
+		if description == "" {
+			continue
+		}
+		rules.Correct(commitment, c.store.Now(), entry.ID, fmt.Sprintf("%s: %s", description, text))
+		if err := c.store.SaveCommitment(commitment); err != nil {
+			return 0, fmt.Errorf("failed to save commitment: %w", err)
+		}
+		if err := c.store.ReplaceCommitment(c.lineDay(fx, &old), &old, commitment); err != nil {
+			return 0, fmt.Errorf("failed to amend commitment line: %w", err)
+		}
+		if old.PersonID != commitment.PersonID {
+			if err := c.dropUnreferenced([]string{old.PersonID}); err != nil {
+				return 0, err
+			}
+		}
+		fmt.Printf("corrected [%s] %s → %s: %s\n", shortID(aliases, commitment.ID), commitment.PersonID, commitment.Expectation.Description, description)
+		changed++
+	}
+	return changed, nil
+}
+
+// dropUnreferenced removes those of people that no commitment or event
+// refers to any more, like the person a correction moved a commitment away
+// from
+func (c *CLI) dropUnreferenced(people []string) error {
+	if len(people) == 0 {
+		return nil
+	}
+	referenced := make(map[string]bool)
+	commitments, err := c.store.ListCommitments()
+	if err != nil {
+		return err
+	}
+	for _, commitment := range commitments {
+		referenced[commitment.PersonID] = true
+	}
+	events, err := c.store.ListEvents()
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		for _, id := range event.Attendees {
+			referenced[id] = true
+		}
+	}
+
+	for _, id := range people {
+		if id == "" || referenced[id] {
+			continue
+		}
+		if err := c.store.DeletePerson(id); err != nil {
+			return fmt.Errorf("failed to remove person: %w", err)
+		}
+	}
+	return nil
+}
+
+// lineDay is the day whose file has the line fx's entry wrote for a
+// commitment: the day it was made, for one the entry created
+func (c *CLI) lineDay(fx *effects, commitment *core.Commitment) time.Time {
+	if commitment.SourceEntry == fx.entry.ID {
+		return c.store.Day(commitment.CreatedAt)
+	}
+	return c.store.Day(fx.entry.Timestamp)
+}
+
+// setCommitmentField sets one corrected field, creating the person or
+// project named if it's new. Dates are read as of when the entry was made.
+func (c *CLI) setCommitmentField(commitment *core.Commitment, field, value string, at time.Time) error {
+	switch field {
+	case "person":
+		person, err := c.store.FindPersonByName(value)
+		if err != nil {
+			person = &core.Person{ID: strings.ToLower(value), Name: strings.ToLower(value), Metadata: make(map[string]any)}
+			if err := c.store.SavePerson(person); err != nil {
+				return fmt.Errorf("failed to save person: %w", err)
+			}
+		}
+		commitment.PersonID = person.ID
+	case "project":
+		id, err := c.ensureProject(value)
+		if err != nil {
+			return err
+		}
+		commitment.ProjectID = id
+	case "deadline":
+		deadline, err := dates.Resolve(value, at.In(c.store.Location()))
+		if err != nil {
+			return fmt.Errorf("couldn't read date %q: %w", value, err)
+		}
+		commitment.Expectation.Deadline = deadline
+	case "description":
+		commitment.Expectation.Description = value
+	case "hardness":
+		value = strings.ToLower(value)
+		if value != "hard" && value != "soft" {
+			return fmt.Errorf("hardness is hard or soft, not %q", value)
+		}
+		commitment.Expectation.Hardness = value
+	}
+	return nil
+}
+
+// ensureProject returns the ID of the project named, creating it if it's
+// new
+func (c *CLI) ensureProject(name string) (string, error) {
+	id := strings.ToLower(name)
+	if _, err := c.store.GetProject(id); err != nil {
+		if err := c.store.SaveProject(&core.Project{ID: id, Metadata: make(map[string]any)}); err != nil {
+			return "", fmt.Errorf("failed to save project: %w", err)
+		}
+	}
+	return id, nil
+}
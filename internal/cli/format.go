@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/heywinit/grechen/internal/dates"
+)
+
+// HandleFmt puts daily files in the layout grechen writes them in
+//
+//	grechen fmt [day...]
+//
+// Every daily file is formatted, or only the given days ("yesterday",
+// 2026-03-06). Timed entries go in time order within their section, with
+// one blank line under each heading; anything else is kept as written. A
+// dry run only lists the files that would change.
+func (c *CLI) HandleFmt(args []string) error {
+	days, err := c.fmtDays(args)
+	if err != nil {
+		return err
+	}
+
+	if c.mode == WriteDryRun {
+		changed, err := c.formatDays(days, false)
+		if err != nil {
+			return err
+		}
+		for _, day := range changed {
+			fmt.Printf("would format %s\n", dailyName(day))
+		}
+		if len(changed) == 0 {
+			fmt.Println("daily files already formatted")
+		}
+		return nil
+	}
+
+	var changed []time.Time
+	err = c.store.Record("grechen fmt", func() error {
+		var err error
+		changed, err = c.formatDays(days, true)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for _, day := range changed {
+		fmt.Printf("formatted %s\n", dailyName(day))
+	}
+	if len(changed) == 0 {
+		fmt.Println("daily files already formatted")
+	}
+	return nil
+}
+
+// fmtDays returns the days named in args, or every day with a daily file
+func (c *CLI) fmtDays(args []string) ([]time.Time, error) {
+	if len(args) == 0 {
+		return c.store.Days()
+	}
+
+	now := c.store.Now()
+	var days []time.Time
+	for _, arg := range args {
+		day, err := dates.Resolve(arg, now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid day %q: %w", arg, err)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// formatDays formats each day's file, writing it back when write is set,
+// and returns the days whose file changed
+func (c *CLI) formatDays(days []time.Time, write bool) ([]time.Time, error) {
+	var changed []time.Time
+	for _, day := range days {
+		doc, err := c.store.ReadDay(day)
+		if err != nil {
+			return nil, err
+		}
+		before := doc.String()
+		doc.Format()
+		if doc.String() == before {
+			continue
+		}
+
+		if write {
+			if err := c.store.WriteDay(doc); err != nil {
+				return nil, err
+			}
+		}
+		changed = append(changed, day)
+	}
+	return changed, nil
+}
+
+// dailyName is a day's file as it's found in the data directory
+func dailyName(day time.Time) string {
+	return "daily/" + day.Format("2006-01-02") + ".md"
+}
@@ -2,15 +2,13 @@ package cli
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/heywinit/grechen/internal/patterns"
 )
 
 // HandleGoodnight implements the goodnight routine
 func (c *CLI) HandleGoodnight() error {
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	today := c.store.Today()
 
 	// Get today's stats
 	todayStats, err := c.stats.ComputeDailyStats(today)
@@ -62,12 +60,14 @@ func (c *CLI) HandleGoodnight() error {
 		for i, q := range questions {
 			notes += fmt.Sprintf("%d. %s\n", i+1, q.Text)
 		}
-		if err := c.store.AppendNote(today, notes); err != nil {
+		err := c.store.Record("goodnight questions", func() error { return c.store.AppendNote(today, notes) })
+		if err != nil {
 			return fmt.Errorf("failed to append questions: %w", err)
 		}
 	} else {
 		fmt.Println("\nno questions today")
 	}
 
+	c.mentionInbox()
 	return nil
 }
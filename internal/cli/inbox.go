@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/store"
+)
+
+// holdUnanswered puts a candidate whose questions couldn't be answered into
+// the inbox instead of dropping it. held is true when it did; other errors
+// are passed through.
+func (c *CLI) holdUnanswered(err error, entry *core.Entry) (held bool, _ error) {
+	var unanswered *unansweredError
+	if !errors.As(err, &unanswered) {
+		return false, err
+	}
+
+	if c.mode == WriteDryRun {
+		for _, q := range unanswered.questions {
+			fmt.Printf("? %s\n", q.Text)
+		}
+		fmt.Println("dry run, not saved to the inbox")
+		return false, errSkipped
+	}
+
+	id, err := c.store.NewID(store.KindPending)
+	if err != nil {
+		return false, err
+	}
+	item := &core.PendingItem{
+		ID:        id,
+		CreatedAt: c.store.Now(),
+		EntryID:   entry.ID,
+		Candidate: unanswered.candidate,
+		Questions: unanswered.questions,
+	}
+	err = c.record(entry.Raw+" (to the inbox)", func() error {
+		if item.ID, err = c.store.ClaimID(store.KindPending, item.ID); err != nil {
+			return err
+		}
+		item.EntryID = c.savedEntryID(item.EntryID)
+		return c.store.SavePending(item)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to save to inbox: %w", err)
+	}
+	id = item.ID
+
+	for _, q := range unanswered.questions {
+		fmt.Printf("? %s\n", q.Text)
+	}
+	fmt.Printf("saved to inbox as %s, answer with `grechen inbox answer %s`\n", id, id)
+	return true, nil
+}
+
+// HandleInbox lists, answers or discards the inputs waiting for answers
+//
+//	grechen inbox
+//	grechen inbox answer <id> [answer]
+//	grechen inbox drop <id>
+//
+// An answer given on the command line answers the item's first question;
+// the rest are asked on the terminal.
+func (c *CLI) HandleInbox(args []string) error {
+	if len(args) == 0 {
+		return c.listInbox()
+	}
+
+	if len(args) < 2 {
+		return fmt.Errorf("usage: grechen inbox [answer <id> [answer] | drop <id>]")
+	}
+	id, err := c.store.ResolveID(store.KindPending, args[1])
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "answer":
+		return c.answerPending(id, strings.Join(args[2:], " "))
+	case "drop":
+		if err := c.store.Record("grechen inbox drop "+id, func() error { return c.store.DeletePending(id) }); err != nil {
+			return err
+		}
+		fmt.Printf("dropped %s\n", id)
+		return nil
+	default:
+		return fmt.Errorf("usage: grechen inbox [answer <id> [answer] | drop <id>]")
+	}
+}
+
+func (c *CLI) listInbox() error {
+	items, err := c.store.ListPending()
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		fmt.Println("inbox is empty")
+		return nil
+	}
+
+	fmt.Printf("inbox (%d):\n", len(items))
+	for _, item := range items {
+		fmt.Printf("  [%s] %s  %s (%s)\n",
+			item.ID,
+			item.CreatedAt.In(c.store.Location()).Format("2006-01-02 15:04"),
+			item.Candidate.Text,
+			item.Candidate.Type)
+		for _, q := range item.Questions {
+			fmt.Printf("     ? %s\n", q.Text)
+		}
+	}
+	return nil
+}
+
+// answerPending resumes an inbox item where it was left. It leaves the
+// inbox once recorded; if questions remain, it stays with the answers given
+// so far.
+func (c *CLI) answerPending(id, answer string) error {
+	item, err := c.store.GetPending(id)
+	if err != nil {
+		return err
+	}
+	entry, err := c.store.GetEntry(item.EntryID)
+	if err != nil {
+		return fmt.Errorf("entry %s for %s is missing: %w", item.EntryID, id, err)
+	}
+	entry = candidateEntry(item.Candidate, entry)
+
+	candidate := item.Candidate
+	questions := item.Questions
+	if answer != "" && len(questions) > 0 {
+		rest := questions[1:]
+		questions = append(c.applyAnswers(&candidate, map[string]string{questions[0].Field: answer}), rest...)
+	}
+
+	err = c.resolveCandidate(candidate, questions, entry, func() error {
+		return c.store.DeletePending(id)
+	})
+	if errors.Is(err, errSkipped) {
+		fmt.Printf("%s stays in the inbox\n", id)
+		return nil
+	}
+	var unanswered *unansweredError
+	if errors.As(err, &unanswered) {
+		item.Candidate = unanswered.candidate
+		item.Questions = unanswered.questions
+		err := c.store.Record("grechen inbox answer "+id, func() error { return c.store.SavePending(item) })
+		if err != nil {
+			return fmt.Errorf("failed to save to inbox: %w", err)
+		}
+		for _, q := range item.Questions {
+			fmt.Printf("? %s\n", q.Text)
+		}
+		fmt.Printf("%s is still waiting for answers\n", id)
+		return nil
+	}
+	return err
+}
+
+// mentionInbox says how many inputs are waiting for answers, if any
+func (c *CLI) mentionInbox() {
+	items, err := c.store.ListPending()
+	if err != nil || len(items) == 0 {
+		return
+	}
+	fmt.Printf("\ninbox: %d waiting for answers (grechen inbox)\n", len(items))
+}
@@ -1,14 +1,123 @@
 package cli
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
 	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/extract"
+	"golang.org/x/term"
 )
 
-// HandleInteractive handles interactive question answering
-// Future: implement with Charm forms for better UX
+// errCancelled is returned when the user gives up on answering questions
+var errCancelled = errors.New("cancelled")
+
+// stdin is shared by every prompt so input typed ahead isn't lost between
+// readers
+var stdin = bufio.NewReader(os.Stdin)
+
+// interactive reports whether questions can be asked: stdin is a terminal
+func interactive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// HandleInteractive asks each question on the terminal and returns the
+// answers keyed by the question's Field. An empty answer skips an optional
+// question and cancels on a required one, as does end of input.
 func (c *CLI) HandleInteractive(questions []core.Question) (map[string]string, error) {
-	// Placeholder for future Charm-based interactive forms
-	// For now, returns empty map
 	answers := make(map[string]string)
+	for _, q := range questions {
+		fmt.Printf("? %s ", q.Text)
+		line, err := stdin.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if err == io.EOF && answer == "" {
+			fmt.Println()
+			return nil, errCancelled
+		}
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read answer: %w", err)
+		}
+
+		if answer == "" {
+			if q.Required {
+				return nil, errCancelled
+			}
+			continue
+		}
+		answers[q.Field] = answer
+	}
 	return answers, nil
 }
+
+// unansweredError is returned when a candidate's questions can't be
+// answered now: there's no terminal to ask on, or the user gave up. The
+// candidate carries the answers given so far.
+type unansweredError struct {
+	candidate core.Candidate
+	questions []core.Question
+}
+
+func (e *unansweredError) Error() string {
+	return "questions need answers"
+}
+
+// answerQuestions asks questions and writes the answers into the
+// candidate's Data, returning any follow-up questions
+func (c *CLI) answerQuestions(candidate *core.Candidate, questions []core.Question) ([]core.Question, error) {
+	answers, err := c.HandleInteractive(questions)
+	if err != nil {
+		return nil, err
+	}
+	return c.applyAnswers(candidate, answers), nil
+}
+
+// applyAnswers writes answers, keyed by question Field, into the
+// candidate's Data. Date answers are resolved like extracted ones; any that
+// can't be read come back as new questions.
+func (c *CLI) applyAnswers(candidate *core.Candidate, answers map[string]string) []core.Question {
+	if candidate.Data == nil {
+		candidate.Data = make(map[string]any)
+	}
+
+	dates := false
+	for field, answer := range answers {
+		switch field {
+		case "person":
+			if person, err := c.store.FindPersonByName(answer); err == nil {
+				answer = person.ID
+			} else {
+				answer = strings.ToLower(answer)
+			}
+		case "project":
+			answer = strings.ToLower(answer)
+		case "expectation.deadline", "deadline", "time", "occurred_at":
+			dates = true
+		}
+		setField(candidate.Data, field, answer)
+	}
+
+	candidate.Questions = nil
+	if dates {
+		extract.ResolveDates(candidate, c.store.Now())
+	}
+	return candidate.Questions
+}
+
+// setField sets the value at a dotted path like "expectation.deadline",
+// creating the maps along the way
+func setField(data map[string]any, path string, value any) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := data[key].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			data[key] = next
+		}
+		data = next
+	}
+	data[keys[len(keys)-1]] = value
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/dates"
+	"github.com/heywinit/grechen/internal/rules"
+	"github.com/heywinit/grechen/internal/store"
+)
+
+// HandleDone marks a commitment fulfilled
+//
+//	grechen done <id-or-prefix>
+func (c *CLI) HandleDone(args []string) error {
+	return c.transition("done", args, core.StatusFulfilled)
+}
+
+// HandleDrop archives a commitment that no longer applies
+//
+//	grechen drop <id-or-prefix>
+func (c *CLI) HandleDrop(args []string) error {
+	return c.transition("drop", args, core.StatusArchived)
+}
+
+// HandleReopen moves a fulfilled, violated or archived commitment back to
+// open. One whose deadline has passed needs a new one, which counts as a
+// slip like any later deadline.
+//
+//	grechen reopen <id-or-prefix> [date]
+func (c *CLI) HandleReopen(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: grechen reopen <id-or-prefix> [date]")
+	}
+
+	now := c.store.Now()
+	phrase := strings.Join(args[1:], " ")
+	var deadline time.Time
+	if phrase != "" {
+		var err error
+		if deadline, err = dates.Resolve(phrase, now); err != nil {
+			return fmt.Errorf("couldn't read date %q: %w", phrase, err)
+		}
+	}
+
+	return c.store.Record("grechen reopen "+strings.Join(args, " "), func() error {
+		commitment, err := c.resolveCommitment(args[0])
+		if err != nil {
+			return err
+		}
+
+		previous := commitment.Expectation.Deadline
+		if err := c.rules.Reopen(commitment, deadline, now, "", "grechen reopen"); err != nil {
+			if phrase == "" && c.rules.Missed(commitment, now) {
+				return fmt.Errorf("%w (grechen reopen %s <date>)", err, args[0])
+			}
+			return err
+		}
+		if err := c.store.SaveCommitment(commitment); err != nil {
+			return fmt.Errorf("failed to update commitment: %w", err)
+		}
+		if err := c.store.AppendCommitment(c.store.Day(now), commitment); err != nil {
+			return fmt.Errorf("failed to append commitment update: %w", err)
+		}
+
+		fmt.Printf("%s: %s → %s", commitment.Status, commitment.PersonID, commitment.Expectation.Description)
+		if !deadline.IsZero() {
+			fmt.Printf(", due %s (was %s)", deadline.Format("2006-01-02"), previous.Format("2006-01-02"))
+		}
+		fmt.Println()
+		return nil
+	})
+}
+
+// HandleSnooze pushes a commitment's deadline back, by default to the day
+// after its current deadline (or after today, if that has passed). Like any
+// later deadline, a snooze counts as a slip.
+//
+//	grechen snooze <id-or-prefix> [date]
+func (c *CLI) HandleSnooze(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: grechen snooze <id-or-prefix> [date]")
+	}
+
+	return c.store.Record("grechen snooze "+strings.Join(args, " "), func() error {
+		commitment, err := c.resolveCommitment(args[0])
+		if err != nil {
+			return err
+		}
+
+		now := c.store.Now()
+		phrase := strings.Join(args[1:], " ")
+		from := commitment.Expectation.Deadline
+		if today := c.store.Day(now); from.Before(today) {
+			from = today
+		}
+		deadline := from.AddDate(0, 0, 1)
+		if phrase != "" {
+			if deadline, err = dates.Resolve(phrase, now); err != nil {
+				return fmt.Errorf("couldn't read date %q: %w", phrase, err)
+			}
+		}
+		return c.renegotiate(commitment, deadline, "", "snoozed")
+	})
+}
+
+// transition moves the commitment named by args to status through the
+// lifecycle rules and records it, without going through extraction
+func (c *CLI) transition(command string, args []string, status core.CommitmentStatus) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: grechen %s <id-or-prefix>", command)
+	}
+
+	return c.store.Record("grechen "+command+" "+args[0], func() error {
+		commitment, err := c.resolveCommitment(args[0])
+		if err != nil {
+			return err
+		}
+
+		now := c.store.Now()
+		if err := rules.Transition(commitment, status, now, "", "grechen "+command); err != nil {
+			return err
+		}
+		if err := c.store.SaveCommitment(commitment); err != nil {
+			return fmt.Errorf("failed to update commitment: %w", err)
+		}
+		if err := c.store.AppendCommitment(c.store.Day(now), commitment); err != nil {
+			return fmt.Errorf("failed to append commitment update: %w", err)
+		}
+
+		fmt.Printf("%s: %s → %s\n", commitment.Status, commitment.PersonID, commitment.Expectation.Description)
+		return nil
+	})
+}
+
+// resolveCommitment finds a commitment by its ID, its alias, or a prefix
+// of only one of them
+func (c *CLI) resolveCommitment(idOrPrefix string) (*core.Commitment, error) {
+	id, err := c.store.ResolveID(store.KindCommitment, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return c.store.GetCommitment(id)
+}
+
+// shortIDs maps the IDs of kind from before short IDs existed to their
+// alias, for display. Without it the full IDs are shown, so a failed lookup
+// isn't an error.
+func (c *CLI) shortIDs(kind string) map[string]string {
+	aliases, err := c.store.Aliases(kind)
+	if err != nil {
+		return nil
+	}
+	return aliases
+}
+
+// shortID is how id is shown: its alias when it has one
+func shortID(aliases map[string]string, id string) string {
+	if alias, ok := aliases[id]; ok {
+		return alias
+	}
+	return id
+}
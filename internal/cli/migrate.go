@@ -0,0 +1,290 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/store"
+)
+
+// CheckTimezone makes sure existing data was written in the configured time
+// zone. Data from before zones were recorded used the system zone. When
+// nothing would move or be re-anchored, the configured zone is adopted
+// silently, except on a dry run; otherwise the user is pointed at
+// `grechen migrate --tz`.
+func (c *CLI) CheckTimezone() error {
+	info, err := c.store.LoadInfo()
+	if err != nil {
+		return err
+	}
+
+	zone := c.store.Location().String()
+	if info.Timezone == zone {
+		return nil
+	}
+
+	from, err := c.recordedLocation(info.Timezone)
+	if err != nil {
+		return err
+	}
+
+	plan, err := c.store.PlanTimezoneMigration(from)
+	if err != nil {
+		return err
+	}
+	if len(plan.Shifts) == 0 && plan.Deadlines == 0 {
+		if c.mode == WriteDryRun {
+			return nil
+		}
+		_, err := c.store.ApplyTimezoneMigration(from)
+		return err
+	}
+
+	if len(plan.Shifts) > 0 {
+		fmt.Printf("note: data was recorded in %s but grechen is using %s; %d log entries are on the wrong day or time\n",
+			from, zone, len(plan.Shifts))
+	} else {
+		fmt.Printf("note: data was recorded in %s but grechen is using %s; %d commitment deadlines are still at midnight %s\n",
+			from, zone, plan.Deadlines, from)
+	}
+	fmt.Println("      run `grechen migrate --tz` to review and fix them")
+	return nil
+}
+
+// recordedLocation returns the zone existing data was written in. Legacy
+// data has none recorded and was written in the system zone.
+func (c *CLI) recordedLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q recorded in store: %w", name, err)
+	}
+	return loc, nil
+}
+
+const migrateUsage = "usage: grechen migrate --tz [--from ZONE] [--apply] | --to sqlite | --ids | --progress"
+
+// HandleMigrate migrates existing data
+//
+//	grechen migrate --tz [--from ZONE] [--apply]
+//	grechen migrate --to sqlite
+//	grechen migrate --ids
+//	grechen migrate --progress
+//
+// --tz moves log entries written with another zone's day boundaries onto the
+// right day and time in the configured zone. Without --apply it only shows
+// what would change. Applying it clears the undo history, which holds the
+// daily files as they were in the old zone.
+//
+// --to sqlite imports the JSON files and daily entries into a SQLite
+// database, which is used from then on.
+//
+// --ids gives commitments and entries recorded before short IDs existed a
+// short alias, and their history events an ID.
+//
+// --progress records the progress in entries from before progress was
+// stored, for those still logged in their daily file.
+func (c *CLI) HandleMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	tz := fs.Bool("tz", false, "move entries into the configured time zone")
+	to := fs.String("to", "", "move the data to another backend (sqlite)")
+	fromName := fs.String("from", "", "zone the existing data was written in (default: recorded zone, or the system zone)")
+	apply := fs.Bool("apply", false, "write the changes instead of only showing them")
+	ids := fs.Bool("ids", false, "give older commitments and entries short ids")
+	progress := fs.Bool("progress", false, "record progress from older entries")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch {
+	case *progress && !*ids && *to == "" && !*tz:
+		return c.migrateProgress()
+	case *ids && *to == "" && !*tz:
+		return c.migrateIDs()
+	case *to != "" && !*tz:
+		return c.migrateBackend(*to)
+	case !*tz:
+		return fmt.Errorf(migrateUsage)
+	}
+
+	info, err := c.store.LoadInfo()
+	if err != nil {
+		return err
+	}
+	name := info.Timezone
+	if *fromName != "" {
+		name = *fromName
+	}
+	from, err := c.recordedLocation(name)
+	if err != nil {
+		return err
+	}
+
+	plan, err := c.store.PlanTimezoneMigration(from)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("migrating from %s to %s\n", plan.From, plan.To)
+	if len(plan.Shifts) == 0 && plan.Deadlines == 0 {
+		fmt.Println("  nothing to change")
+	}
+	for _, shift := range plan.Shifts {
+		fmt.Printf("  %s → %s  %s\n", shift.From.Format("2006-01-02 15:04"), shift.To.Format("2006-01-02 15:04"), shift.Text)
+	}
+	if plan.Deadlines > 0 {
+		fmt.Printf("  %d commitment deadlines re-anchored to midnight %s\n", plan.Deadlines, plan.To)
+	}
+
+	if !*apply {
+		if len(plan.Shifts) > 0 || plan.Deadlines > 0 {
+			fmt.Println("\nrun again with --apply to make these changes")
+		}
+		return nil
+	}
+
+	if _, err := c.store.ApplyTimezoneMigration(from); err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+	fmt.Println("\nmigrated")
+	if len(plan.Shifts) > 0 || plan.Deadlines > 0 {
+		fmt.Println("undo history cleared, it held these entries in the old zone")
+	}
+	return nil
+}
+
+// migrateIDs assigns short aliases to records with long IDs
+func (c *CLI) migrateIDs() error {
+	var m *store.AliasMigration
+	err := c.store.Record("grechen migrate --ids", func() error {
+		var err error
+		m, err = c.store.AssignAliases()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+	if m.Commitments == 0 && m.Entries == 0 && m.Events == 0 {
+		fmt.Println("everything already has a short id")
+		return nil
+	}
+	fmt.Printf("gave %d commitments, %d entries and %d history events short ids\n", m.Commitments, m.Entries, m.Events)
+	return nil
+}
+
+// migrateBackend moves the data to another storage backend
+func (c *CLI) migrateBackend(to string) error {
+	if to != "sqlite" {
+		return fmt.Errorf("unknown backend %q (supported: sqlite)", to)
+	}
+
+	fileStore, ok := c.store.(*store.FileStore)
+	if !ok {
+		return fmt.Errorf("data is already stored in %s", c.store.Backend())
+	}
+
+	m, err := fileStore.MigrateToSQLite()
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	fmt.Printf("imported %d people, %d projects, %d commitments (%d journal events), %d inputs and %d daily entries into sqlite\n",
+		m.People, m.Projects, m.Commitments, m.Events, m.Inputs, m.Entries)
+	if m.Pending > 0 {
+		fmt.Printf("plus %d inbox items\n", m.Pending)
+	}
+	if m.Calendar > 0 {
+		fmt.Printf("plus %d calendar events\n", m.Calendar)
+	}
+	if m.Progress > 0 {
+		fmt.Printf("plus %d progress records\n", m.Progress)
+	}
+	fmt.Printf("the json files in %s are no longer used and can be kept as a backup\n", c.store.MetaDir())
+	return nil
+}
+
+// migrateProgress records the progress candidates of earlier entries that
+// have no progress record yet. Entries whose log line is gone, undone or
+// corrected away, are left out.
+func (c *CLI) migrateProgress() error {
+	entries, err := c.store.ListEntries()
+	if err != nil {
+		return err
+	}
+	existing, err := c.store.ListProgress()
+	if err != nil {
+		return err
+	}
+	recorded := make(map[string]bool, len(existing))
+	for _, p := range existing {
+		recorded[p.SourceEntry] = true
+	}
+
+	added := 0
+	err = c.store.Record("grechen migrate --progress", func() error {
+		days := make(map[string][]store.DailyEntry)
+		for _, entry := range entries {
+			if recorded[entry.ID] {
+				continue
+			}
+			for _, candidate := range entry.Candidates {
+				projectID, _ := candidate.Data["project"].(string)
+				if candidate.Type != core.IntentProgress || projectID == "" {
+					continue
+				}
+
+				written := entry
+				if len(entry.Candidates) > 1 {
+					written = candidateEntry(candidate, entry)
+				}
+				written = c.occurredEntry(candidate, written)
+
+				day := c.store.Day(written.Timestamp).Format("2006-01-02")
+				lines, ok := days[day]
+				if !ok {
+					if lines, err = c.store.ListDailyEntries(written.Timestamp); err != nil {
+						return err
+					}
+					days[day] = lines
+				}
+				if !c.hasLogLine(lines, written) {
+					continue
+				}
+
+				id, err := c.store.NewID(store.KindProgress)
+				if err != nil {
+					return err
+				}
+				status, _ := candidate.Data["status"].(string)
+				notes, _ := candidate.Data["notes"].(string)
+				progress := &core.Progress{
+					ID:          id,
+					Timestamp:   written.Timestamp,
+					SourceEntry: entry.ID,
+					ProjectID:   projectID,
+					Status:      status,
+					Notes:       notes,
+				}
+				if err := c.store.SaveProgress(progress); err != nil {
+					return fmt.Errorf("failed to save progress: %w", err)
+				}
+				added++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if added == 0 {
+		fmt.Println("no earlier progress to record")
+		return nil
+	}
+	fmt.Printf("recorded %d progress entries from earlier inputs\n", added)
+	return nil
+}
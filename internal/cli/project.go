@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/store"
+)
+
+// timelineItem is one dated line in a project's timeline
+type timelineItem struct {
+	at   time.Time
+	text string
+}
+
+// HandleProject shows a project's timeline
+//
+//	grechen project <id>
+//
+// The timeline has the progress reported on the project, what happened to
+// its commitments, and its calendar events, oldest first.
+func (c *CLI) HandleProject(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: grechen project <id>")
+	}
+	id := strings.ToLower(args[0])
+
+	project, err := c.store.GetProject(id)
+	if err != nil {
+		return fmt.Errorf("no project %s (see `grechen projects`)", id)
+	}
+	progress, err := c.store.ListProgressByProject(id)
+	if err != nil {
+		return err
+	}
+	commitments, err := c.store.ListCommitmentsByProject(id)
+	if err != nil {
+		return err
+	}
+	events, err := c.store.ListEvents()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("project %s (priority: %s)\n", project.ID, priorityName(project.Priority))
+	open := 0
+	for _, commitment := range commitments {
+		if commitment.Status == core.StatusOpen || commitment.Status == core.StatusUpdated {
+			open++
+		}
+	}
+	fmt.Printf("  progress: %d", len(progress))
+	if len(progress) > 0 {
+		last := progress[len(progress)-1]
+		fmt.Printf(" (last %s, %s)", last.Timestamp.In(c.store.Location()).Format("2006-01-02"), orNone(last.Status))
+	}
+	fmt.Println()
+	fmt.Printf("  commitments: %d (%d open)\n", len(commitments), open)
+
+	var items []timelineItem
+	for _, p := range progress {
+		text := "progress"
+		if p.Status != "" {
+			text += " (" + p.Status + ")"
+		}
+		if p.Notes != "" {
+			text += ": " + p.Notes
+		}
+		items = append(items, timelineItem{at: p.Timestamp, text: text})
+	}
+
+	aliases := c.shortIDs(store.KindCommitment)
+	for _, commitment := range commitments {
+		for _, event := range commitment.History {
+			text := fmt.Sprintf("[%s] %s → %s: %s", shortID(aliases, commitment.ID), commitment.PersonID, commitment.Expectation.Description, event.Type)
+			if event.Type == "created" {
+				text += fmt.Sprintf(" (due %s)", commitment.Expectation.Deadline.Format("2006-01-02"))
+			}
+			items = append(items, timelineItem{at: event.Timestamp, text: text})
+		}
+	}
+
+	for _, event := range events {
+		if event.ProjectID == id {
+			items = append(items, timelineItem{at: event.Start, text: fmt.Sprintf("event [%s] %s", event.ID, event.Title)})
+		}
+	}
+
+	if len(items) == 0 {
+		fmt.Println("\nnothing recorded on it yet")
+		return nil
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].at.Before(items[j].at) })
+	fmt.Println("\ntimeline:")
+	for _, item := range items {
+		fmt.Printf("  %s  %s\n", item.at.In(c.store.Location()).Format("2006-01-02 15:04"), item.text)
+	}
+	return nil
+}
+
+// priorityName names a project priority the way `projects` shows it
+func priorityName(priority int) string {
+	switch {
+	case priority > 0:
+		return "high"
+	case priority < 0:
+		return "low"
+	}
+	return "normal"
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/dates"
+	"github.com/heywinit/grechen/internal/rules"
+)
+
+// HandleRenegotiate moves a commitment's deadline without going through
+// extraction
+//
+//	grechen renegotiate <id-or-prefix> <date>
+//
+// The date takes the same forms as in free text ("monday", "next week",
+// 2026-03-01). The old deadline stays in the commitment's history.
+func (c *CLI) HandleRenegotiate(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: grechen renegotiate <id-or-prefix> <date>")
+	}
+	phrase := strings.Join(args[1:], " ")
+	deadline, err := dates.Resolve(phrase, c.store.Now())
+	if err != nil {
+		return fmt.Errorf("couldn't read date %q: %w", phrase, err)
+	}
+
+	return c.store.Record("grechen renegotiate "+strings.Join(args, " "), func() error {
+		commitment, err := c.resolveCommitment(args[0])
+		if err != nil {
+			return err
+		}
+		return c.renegotiate(commitment, deadline, "", "moved to "+phrase)
+	})
+}
+
+// renegotiate moves commitment to deadline, saves it and notes it in
+// today's file. Callers hold the store lock.
+func (c *CLI) renegotiate(commitment *core.Commitment, deadline time.Time, entryID, description string) error {
+	now := c.store.Now()
+	previous := commitment.Expectation.Deadline
+
+	if err := rules.Renegotiate(commitment, deadline, now, entryID, description); err != nil {
+		return err
+	}
+	if err := c.store.SaveCommitment(commitment); err != nil {
+		return fmt.Errorf("failed to update commitment: %w", err)
+	}
+	if err := c.store.AppendCommitment(c.store.Day(now), commitment); err != nil {
+		return fmt.Errorf("failed to append commitment update: %w", err)
+	}
+
+	fmt.Printf("moved %s → %s to %s", commitment.PersonID, commitment.Expectation.Description, deadline.Format("2006-01-02"))
+	if !previous.IsZero() {
+		fmt.Printf(" (was %s", previous.Format("2006-01-02"))
+		if commitment.Slips > 0 {
+			fmt.Printf(", slip #%d", commitment.Slips)
+		}
+		fmt.Print(")")
+	}
+	fmt.Println()
+	return nil
+}
@@ -22,12 +22,16 @@ func (c *CLI) HandleSetup() error {
 	}
 	fmt.Println("  created directory structure")
 
-	// Create empty JSON files if they don't exist
+	// Create empty JSON files if they don't exist; the SQLite backend keeps
+	// everything in its database instead
 	files := map[string][]byte{
 		"people.json":      []byte("[]\n"),
 		"projects.json":    []byte("[]\n"),
 		"commitments.json": []byte("[]\n"),
 	}
+	if c.store.Backend() != "json" {
+		files = nil
+	}
 
 	for filename, content := range files {
 		filepath := filepath.Join(metaDir, filename)
@@ -48,11 +52,19 @@ func (c *CLI) HandleSetup() error {
 			"## Structure\n\n" +
 			"- `daily/` - Daily markdown files (YYYY-MM-DD.md)\n" +
 			"  - Each file contains sections: ## logs, ## commitments, ## notes\n" +
+			"  - Safe to edit by hand; anything else in a file is kept as written\n" +
 			"  - Files are append-only, never rewritten\n\n" +
 			"- `meta/` - Metadata storage (JSON files)\n" +
 			"  - `people.json` - People you interact with\n" +
 			"  - `projects.json` - Projects you work on\n" +
-			"  - `commitments.json` - All commitments and their history\n\n" +
+			"  - `commitments.jsonl` - Append-only journal of every commitment change\n" +
+			"  - `commitments.json` - Snapshot of the journal, refreshed periodically\n" +
+			"  - `entries.jsonl` - Every input with what was extracted from it\n" +
+			"  - `aliases.json` - Short aliases for IDs from before short IDs\n" +
+			"  - `inbox.json` - Inputs waiting for answers to their questions\n" +
+			"  - `events.json` - Calendar events: meetings, calls, appointments\n" +
+			"  - `progress.json` - Progress reported on each project\n" +
+			"  - `undo.json` - The last operations, for `grechen undo` and `grechen redo`\n\n" +
 			"## Adding Data\n\n" +
 			"People and projects are automatically created when you mention them in your logs:\n\n" +
 			"```\n" +
@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/store"
+)
+
+// HandleShow shows where an entry, commitment, calendar event or progress
+// record came from
+//
+//	grechen show <id-or-prefix>
+//
+// For an entry: the input as given, what extraction made of it and the
+// commitments, events and progress it created. For a commitment: its state
+// and history, and the entry it came from. For a history event: the
+// commitment it belongs to. For a calendar event or progress record: what
+// it says and the entry it came from.
+func (c *CLI) HandleShow(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: grechen show <id-or-prefix>")
+	}
+
+	// The ID may be a prefix, and the same prefix could name records of
+	// different kinds
+	type match struct{ kind, id string }
+	var matches []match
+	var ambiguous error
+	for _, kind := range []string{store.KindCommitment, store.KindEntry, store.KindEvent, store.KindCalendar, store.KindProgress} {
+		id, err := c.store.ResolveID(kind, args[0])
+		var idErr *store.IDError
+		switch {
+		case err == nil:
+			matches = append(matches, match{kind, id})
+		case errors.As(err, &idErr) && len(idErr.Matches) > 0:
+			ambiguous = err
+		case !errors.As(err, &idErr):
+			return err
+		}
+	}
+	if ambiguous != nil {
+		return ambiguous
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no entry, commitment or event with id %s", args[0])
+	}
+	if len(matches) > 1 {
+		return fmt.Errorf("%s matches more than one kind of record, give more of the id", args[0])
+	}
+
+	switch id := matches[0].id; matches[0].kind {
+	case store.KindCommitment:
+		commitment, err := c.store.GetCommitment(id)
+		if err != nil {
+			return err
+		}
+		return c.showCommitment(commitment)
+	case store.KindEntry:
+		entry, err := c.store.GetEntry(id)
+		if err != nil {
+			return err
+		}
+		return c.showEntry(entry)
+	case store.KindCalendar:
+		event, err := c.store.GetEvent(id)
+		if err != nil {
+			return err
+		}
+		return c.showCalendarEvent(event)
+	case store.KindProgress:
+		progress, err := c.store.GetProgress(id)
+		if err != nil {
+			return err
+		}
+		return c.showProgress(progress)
+	default:
+		return c.showEvent(id)
+	}
+}
+
+// showEvent shows the commitment a history event belongs to
+func (c *CLI) showEvent(id string) error {
+	commitments, err := c.store.ListCommitments()
+	if err != nil {
+		return err
+	}
+	for _, commitment := range commitments {
+		for _, event := range commitment.History {
+			if event.ID == id {
+				fmt.Printf("event %s: %s\n\n", id, event.Type)
+				return c.showCommitment(commitment)
+			}
+		}
+	}
+	return fmt.Errorf("no event with id %s", id)
+}
+
+func (c *CLI) showCommitment(commitment *core.Commitment) error {
+	entryAliases := c.shortIDs(store.KindEntry)
+	fmt.Printf("commitment %s\n", shortID(c.shortIDs(store.KindCommitment), commitment.ID))
+	fmt.Printf("  to: %s\n", commitment.PersonID)
+	if commitment.ProjectID != "" {
+		fmt.Printf("  project: %s\n", commitment.ProjectID)
+	}
+	fmt.Printf("  expectation: %s\n", commitment.Expectation.Description)
+	if !commitment.Expectation.Deadline.IsZero() {
+		fmt.Printf("  due: %s", commitment.Expectation.Deadline.Format("2006-01-02"))
+		if commitment.Expectation.Hardness != "" {
+			fmt.Printf(" (%s)", commitment.Expectation.Hardness)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("  status: %s\n", commitment.Status)
+	fmt.Printf("  created: %s\n", commitment.CreatedAt.In(c.store.Location()).Format("2006-01-02 15:04"))
+
+	if len(commitment.History) > 0 {
+		fmt.Println("\nhistory:")
+		for _, event := range commitment.History {
+			fmt.Printf("  %s  %s", event.Timestamp.In(c.store.Location()).Format("2006-01-02 15:04"), event.Type)
+			if event.ID != "" {
+				fmt.Printf(" [%s]", event.ID)
+			}
+			if event.Description != "" {
+				fmt.Printf(": %s", event.Description)
+			}
+			if event.Deadline != nil && !event.Deadline.IsZero() {
+				fmt.Printf(" (was due %s)", event.Deadline.Format("2006-01-02"))
+			}
+			if event.EntryID != "" {
+				fmt.Printf(" (entry %s)", shortID(entryAliases, event.EntryID))
+			}
+			fmt.Println()
+		}
+	}
+
+	c.printSource(commitment.SourceEntry)
+	return nil
+}
+
+func (c *CLI) showCalendarEvent(event *core.Event) error {
+	fmt.Printf("event %s\n", event.ID)
+	fmt.Printf("  title: %s\n", event.Title)
+	fmt.Printf("  when: %s\n", c.eventWhen(event))
+	if len(event.Attendees) > 0 {
+		fmt.Printf("  with: %s\n", strings.Join(event.Attendees, ", "))
+	}
+	if event.ProjectID != "" {
+		fmt.Printf("  project: %s\n", event.ProjectID)
+	}
+	fmt.Printf("  created: %s\n", event.CreatedAt.In(c.store.Location()).Format("2006-01-02 15:04"))
+
+	c.printSource(event.SourceEntry)
+	return nil
+}
+
+func (c *CLI) showProgress(progress *core.Progress) error {
+	fmt.Printf("progress %s\n", progress.ID)
+	fmt.Printf("  project: %s\n", progress.ProjectID)
+	fmt.Printf("  status: %s\n", orNone(progress.Status))
+	if progress.Notes != "" {
+		fmt.Printf("  notes: %s\n", progress.Notes)
+	}
+	fmt.Printf("  at: %s\n", progress.Timestamp.In(c.store.Location()).Format("2006-01-02 15:04"))
+	c.printSource(progress.SourceEntry)
+	return nil
+}
+
+// printSource prints the entry a record came from
+func (c *CLI) printSource(entryID string) {
+	if entryID == "" {
+		return
+	}
+	entry, err := c.store.GetEntry(entryID)
+	if err != nil {
+		fmt.Printf("\nsource entry %s is not in the entry log\n", shortID(c.shortIDs(store.KindEntry), entryID))
+		return
+	}
+	fmt.Println("\nsource:")
+	c.printEntry(entry)
+}
+
+func (c *CLI) showEntry(entry *core.Entry) error {
+	fmt.Printf("entry %s\n", shortID(c.shortIDs(store.KindEntry), entry.ID))
+	c.printEntry(entry)
+
+	commitments, err := c.store.ListCommitments()
+	if err != nil {
+		return err
+	}
+	var created []*core.Commitment
+	for _, commitment := range commitments {
+		if commitment.SourceEntry == entry.ID {
+			created = append(created, commitment)
+		}
+	}
+	if len(created) > 0 {
+		aliases := c.shortIDs(store.KindCommitment)
+		fmt.Println("\ncommitments:")
+		for _, commitment := range created {
+			fmt.Printf("  [%s] %s → %s (status: %s)\n",
+				shortID(aliases, commitment.ID), commitment.PersonID, commitment.Expectation.Description, commitment.Status)
+		}
+	}
+
+	events, err := c.store.ListEvents()
+	if err != nil {
+		return err
+	}
+	var scheduled []*core.Event
+	for _, event := range events {
+		if event.SourceEntry == entry.ID {
+			scheduled = append(scheduled, event)
+		}
+	}
+	if len(scheduled) > 0 {
+		fmt.Println("\nevents:")
+		for _, event := range scheduled {
+			c.printEvent(event, true)
+		}
+	}
+
+	progress, err := c.store.ListProgress()
+	if err != nil {
+		return err
+	}
+	var reported []*core.Progress
+	for _, p := range progress {
+		if p.SourceEntry == entry.ID {
+			reported = append(reported, p)
+		}
+	}
+	if len(reported) > 0 {
+		fmt.Println("\nprogress:")
+		for _, p := range reported {
+			fmt.Printf("  [%s] %s: %s\n", p.ID, p.ProjectID, orNone(p.Status))
+		}
+	}
+	return nil
+}
+
+func (c *CLI) printEntry(entry *core.Entry) {
+	fmt.Printf("  at: %s\n", entry.Timestamp.In(c.store.Location()).Format("2006-01-02 15:04"))
+	fmt.Printf("  input: %s\n", entry.Raw)
+	if entry.Source != "" {
+		fmt.Printf("  extracted by: %s (confidence: %.2f)\n", entry.Source, entry.Confidence)
+	}
+	for i, candidate := range entry.Candidates {
+		data, err := json.MarshalIndent(candidate.Data, "    ", "  ")
+		if err != nil {
+			data = []byte(fmt.Sprint(candidate.Data))
+		}
+		fmt.Printf("  candidate %d: %s (confidence: %.2f)\n    %s\n", i+1, candidate.Type, candidate.Confidence, data)
+	}
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/heywinit/grechen/internal/store"
+)
+
+// Sweep marks commitments whose deadline has passed as violated and says
+// which ones. It runs before every command, so missed deadlines surface the
+// next time grechen is used.
+func (c *CLI) Sweep() error {
+	violated, err := c.rules.Sweep(c.store.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, commitment := range violated {
+		fmt.Printf("missed: %s → %s (due %s, %s)\n",
+			commitment.PersonID,
+			commitment.Expectation.Description,
+			commitment.Expectation.Deadline.Format("2006-01-02"),
+			commitment.Expectation.Hardness)
+	}
+	if len(violated) > 0 {
+		fmt.Println()
+	}
+	return nil
+}
+
+// HandleTick runs the violation sweep on its own, e.g. from cron
+func (c *CLI) HandleTick() error {
+	violated, err := c.rules.Sweep(c.store.Now())
+	if err != nil {
+		return err
+	}
+
+	if len(violated) == 0 {
+		fmt.Println("no missed deadlines")
+		return nil
+	}
+
+	aliases := c.shortIDs(store.KindCommitment)
+	fmt.Printf("%d commitments marked violated:\n", len(violated))
+	for _, commitment := range violated {
+		fmt.Printf("  [%s] %s → %s (due %s, %s)\n",
+			shortID(aliases, commitment.ID),
+			commitment.PersonID,
+			commitment.Expectation.Description,
+			commitment.Expectation.Deadline.Format("2006-01-02"),
+			commitment.Expectation.Hardness)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/heywinit/grechen/internal/store"
+)
+
+// HandleUndo takes back the last n recorded operations (1 by default):
+// everything an input, correction or command changed in commitments,
+// people, projects, the inbox, the entry log, aliases and the daily files.
+// The missed-deadline sweep is recorded like a command. The commitment
+// journal keeps its history; undo adds the events that put each commitment
+// back.
+//
+//	grechen undo [n]
+func (c *CLI) HandleUndo(args []string) error {
+	n, err := opCount("undo", args)
+	if err != nil {
+		return err
+	}
+	ops, err := c.store.Undo(n)
+	if err != nil {
+		return err
+	}
+	if len(ops) == 0 {
+		fmt.Println("nothing to undo")
+		return nil
+	}
+	c.printOps("undid", ops)
+	return nil
+}
+
+// HandleRedo makes the last n undone operations again (1 by default), as
+// long as nothing was recorded since they were undone
+//
+//	grechen redo [n]
+func (c *CLI) HandleRedo(args []string) error {
+	n, err := opCount("redo", args)
+	if err != nil {
+		return err
+	}
+	ops, err := c.store.Redo(n)
+	if err != nil {
+		return err
+	}
+	if len(ops) == 0 {
+		fmt.Println("nothing to redo")
+		return nil
+	}
+	c.printOps("redid", ops)
+	return nil
+}
+
+func opCount(command string, args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if len(args) > 1 || err != nil || n < 1 {
+		return 0, fmt.Errorf("usage: grechen %s [n]", command)
+	}
+	return n, nil
+}
+
+func (c *CLI) printOps(verb string, ops []*store.Op) {
+	for _, op := range ops {
+		fmt.Printf("%s: %s (%s, %s)\n", verb, op.Label, op.At.In(c.store.Location()).Format("2006-01-02 15:04"), op.Summary())
+		for _, what := range op.Drifted {
+			fmt.Printf("  %s had changed since, those changes are gone too\n", what)
+		}
+	}
+}
@@ -3,9 +3,12 @@ package core
 import "time"
 
 type Entry struct {
-	ID        string
-	Timestamp time.Time
-	Raw       string
+	ID         string
+	Timestamp  time.Time
+	Raw        string
+	Candidates []Candidate // what extraction made of Raw
+	Source     string      // extractor that produced the candidates, e.g. "gemini/gemini-2.5-flash"
+	Confidence float64     // lowest confidence among the candidates
 }
 
 type IntentType string
@@ -17,6 +20,9 @@ const (
 	IntentUpdate     IntentType = "update"
 	IntentEvent      IntentType = "event"
 	IntentCorrection IntentType = "correction"
+
+	// IntentRenegotiation moves an existing commitment's deadline
+	IntentRenegotiation IntentType = "renegotiation"
 )
 
 type Intent struct {
@@ -45,6 +51,7 @@ type Commitment struct {
 	Status       CommitmentStatus
 	LastUpdateAt *time.Time
 	History      []CommitmentEvent
+	Slips        int // times the deadline was renegotiated to a later day
 }
 
 type Expectation struct {
@@ -53,6 +60,29 @@ type Expectation struct {
 	Hardness    string // "hard" | "soft"
 }
 
+// Event is something on the calendar: a meeting, call or appointment
+type Event struct {
+	ID          string
+	CreatedAt   time.Time
+	SourceEntry string
+	Title       string
+	Start       time.Time
+	End         *time.Time // nil when only the start is known
+	AllDay      bool       // no time of day was given; Start is midnight
+	Attendees   []string   // person IDs
+	ProjectID   string
+}
+
+// Progress is a step forward on a project, as reported in an entry
+type Progress struct {
+	ID          string
+	Timestamp   time.Time
+	SourceEntry string
+	ProjectID   string
+	Status      string // e.g. "in progress", "done"
+	Notes       string
+}
+
 type Person struct {
 	ID       string
 	Name     string
@@ -66,14 +96,18 @@ type Project struct {
 }
 
 type CommitmentEvent struct {
+	ID          string // short ID (ev-...), assigned when the commitment is saved
 	Timestamp   time.Time
-	Type        string // "created", "updated", "fulfilled", "violated", "archived"
+	Type        string // "created", "renegotiated", "corrected", or the status moved to
 	Description string
+	EntryID     string     // entry that triggered the change, empty for automatic ones
+	Deadline    *time.Time // for "renegotiated", the deadline that was replaced
 }
 
 type Candidate struct {
 	Type       IntentType
 	Confidence float64
+	Text       string // part of the input this candidate was extracted from
 	Data       map[string]any
 	Questions  []Question
 }
@@ -85,6 +119,15 @@ type Question struct {
 	Field    string // field name this question is about
 }
 
+// PendingItem is a candidate put aside until its questions are answered
+type PendingItem struct {
+	ID        string
+	CreatedAt time.Time
+	EntryID   string // entry the candidate was extracted from
+	Candidate Candidate
+	Questions []Question
+}
+
 type Deviation struct {
 	Pattern  PatternType
 	Severity string // "low", "medium", "high"
@@ -0,0 +1,388 @@
+// Package dates resolves natural-language date expressions ("friday",
+// "end of week", "in 3 business days") against an injected now, so the same
+// phrase always gives the same date for the same moment.
+package dates
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Resolve turns a date expression into midnight of the day it refers to, in
+// now's location. Supported forms:
+//
+//   - explicit dates: 2006-01-02, "march 3", "3 march"
+//   - today, tonight, tomorrow, day after tomorrow, yesterday
+//   - weekday names: "friday" and "this friday" are the next occurrence
+//     (today included), "next friday" is the friday of next week
+//   - eod, cob, end of day; eow, end of week, end of next week; eom, end of month
+//   - next week (a week from today), next month (first day of next month)
+//   - in N days/weeks/months, N days from now, in a couple of days
+//   - business days: next business day, in N business/working days
+//
+// Leading "by", "before", "on", "due", "until" are ignored.
+func Resolve(expr string, now time.Time) (time.Time, error) {
+	phrase := normalize(expr)
+	if phrase == "" {
+		return time.Time{}, fmt.Errorf("empty date expression")
+	}
+
+	today := startOfDay(now)
+
+	if t, err := time.ParseInLocation("2006-01-02", phrase, now.Location()); err == nil {
+		return t, nil
+	}
+
+	switch phrase {
+	case "today", "tonight", "eod", "end of day", "end of today", "end of the day",
+		"cob", "close of business", "end of business day", "later today", "this evening":
+		return today, nil
+	case "tomorrow", "tmrw", "tmr":
+		return today.AddDate(0, 0, 1), nil
+	case "day after tomorrow", "the day after tomorrow":
+		return today.AddDate(0, 0, 2), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "eow", "end of week", "end of the week", "this week":
+		return endOfWeek(today), nil
+	case "end of next week":
+		return endOfWeek(today).AddDate(0, 0, 7), nil
+	case "next week":
+		return today.AddDate(0, 0, 7), nil
+	case "eom", "end of month", "end of the month", "this month":
+		return time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, now.Location()), nil
+	case "next month":
+		return time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, now.Location()), nil
+	case "next business day", "next working day":
+		return addBusinessDays(today, 1), nil
+	}
+
+	if t, ok := resolveOffset(phrase, today); ok {
+		return t, nil
+	}
+	if t, ok := resolveWeekday(phrase, today); ok {
+		return t, nil
+	}
+	if t, ok := resolveMonthDay(phrase, today); ok {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognised date expression: %q", expr)
+}
+
+// ResolvePast resolves expr like Resolve, but reads a date given without a
+// year or week ("march 3", "friday") as the most recent one rather than the
+// next, for looking back at history. "last friday" is the friday before
+// today.
+func ResolvePast(expr string, now time.Time) (time.Time, error) {
+	today := startOfDay(now)
+	if rest, ok := strings.CutPrefix(normalize(expr), "last "); ok {
+		if weekday, ok := parseWeekday(rest); ok {
+			days := (int(today.Weekday()) - int(weekday) + 6) % 7
+			return today.AddDate(0, 0, -days-1), nil
+		}
+	}
+
+	t, err := Resolve(expr, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !t.After(today) {
+		return t, nil
+	}
+
+	phrase := normalize(expr)
+	if _, ok := resolveMonthDay(phrase, today); ok {
+		return t.AddDate(-1, 0, 0), nil
+	}
+	if _, ok := parseWeekday(strings.TrimPrefix(phrase, "this ")); ok {
+		return t.AddDate(0, 0, -7), nil
+	}
+	return t, nil
+}
+
+// ResolveDateTime resolves an expression that may carry a time of day, such
+// as "tomorrow at 6pm" or "friday 14:30". hasClock reports whether a time was
+// given; without one the result is midnight.
+func ResolveDateTime(expr string, now time.Time) (t time.Time, hasClock bool, err error) {
+	return resolveDateTime(expr, now, Resolve)
+}
+
+// ResolvePastDateTime resolves an expression like ResolveDateTime, for when
+// something happened rather than when it's due: dates are read as
+// ResolvePast reads them, and a time of day alone that's still to come
+// today is yesterday's. A time after now is an error.
+func ResolvePastDateTime(expr string, now time.Time) (t time.Time, hasClock bool, err error) {
+	t, hasClock, err = resolveDateTime(expr, now, ResolvePast)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if t.After(now) && startOfDay(t).Equal(startOfDay(now)) && !strings.Contains(normalize(expr), "today") {
+		t = t.AddDate(0, 0, -1)
+	}
+	if t.After(now) {
+		return time.Time{}, false, fmt.Errorf("%q is in the future", expr)
+	}
+	return t, hasClock, nil
+}
+
+func resolveDateTime(expr string, now time.Time, resolve func(string, time.Time) (time.Time, error)) (t time.Time, hasClock bool, err error) {
+	phrase := normalize(expr)
+
+	if t, err := time.ParseInLocation("2006-01-02 15:04", phrase, now.Location()); err == nil {
+		return t, true, nil
+	}
+
+	datePart := phrase
+	var hour, minute int
+	if m := clockSuffix.FindStringSubmatchIndex(phrase); m != nil {
+		start, end := m[2], m[3]
+		if start < 0 {
+			start, end = m[4], m[5]
+		}
+		if h, mm, ok := ParseClock(phrase[start:end]); ok {
+			hour, minute, hasClock = h, mm, true
+			datePart = strings.TrimSpace(phrase[:m[0]])
+		}
+	}
+
+	day := startOfDay(now)
+	if datePart != "" {
+		day, err = resolve(datePart, now)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, now.Location()), hasClock, nil
+}
+
+var (
+	// A trailing time needs "at", a colon or am/pm, so "march 3" stays a date
+	clockSuffix = regexp.MustCompile(`(?:^|\s)(?:(?:at|@)\s*(\d{1,2}(?::\d{2})?\s*(?:am|pm)?|noon|midnight)|(\d{1,2}:\d{2}\s*(?:am|pm)?|\d{1,2}\s*(?:am|pm)|noon|midnight))$`)
+	clockValue  = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+)
+
+// ParseClock parses a time of day like "6pm", "14:30", "9:15am" or "noon".
+// Bare hours from 1 to 7 are read as afternoon, since that's what "call at 6"
+// almost always means.
+func ParseClock(s string) (hour, minute int, ok bool) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	switch s {
+	case "noon", "midday":
+		return 12, 0, true
+	case "midnight":
+		return 0, 0, true
+	}
+
+	m := clockValue.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0, false
+	}
+	hour, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	if m[3] != "" && (hour < 1 || hour > 12) {
+		return 0, 0, false
+	}
+
+	switch {
+	case m[3] == "pm" && hour < 12:
+		hour += 12
+	case m[3] == "am" && hour == 12:
+		hour = 0
+	case m[3] == "" && m[2] == "" && hour >= 1 && hour < 8:
+		hour += 12
+	}
+
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+// Find scans free text for the longest date expression it can resolve and
+// returns the date along with the phrase that matched
+func Find(text string, now time.Time) (time.Time, string, bool) {
+	words := strings.Fields(strings.ToLower(text))
+	for i := range words {
+		for n := min(6, len(words)-i); n > 0; n-- {
+			phrase := strings.Join(words[i:i+n], " ")
+			phrase = strings.Trim(phrase, ".,;:!?")
+			if fillerOnly(phrase) {
+				continue
+			}
+			if t, err := Resolve(phrase, now); err == nil {
+				return t, phrase, true
+			}
+		}
+	}
+	return time.Time{}, "", false
+}
+
+// fillerOnly reports phrases that resolve but are too vague or too likely to
+// be ordinary words ("sun", "wed") to count as a date in free text
+func fillerOnly(phrase string) bool {
+	switch phrase {
+	case "this week", "this month", "this evening", "later today":
+		return true
+	case "eod", "eow", "eom", "cob", "tmr", "tmrw":
+		return false
+	}
+	return !strings.Contains(phrase, " ") && len(phrase) <= 4
+}
+
+func normalize(expr string) string {
+	phrase := strings.ToLower(strings.TrimSpace(expr))
+	phrase = strings.Trim(phrase, ".,;:!?")
+	phrase = strings.Join(strings.Fields(phrase), " ")
+	for _, prefix := range []string{"by ", "before ", "on ", "due ", "until ", "till ", "this coming ", "coming "} {
+		phrase = strings.TrimPrefix(phrase, prefix)
+	}
+	return phrase
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// endOfWeek is the coming friday, or today on a weekend
+func endOfWeek(today time.Time) time.Time {
+	switch today.Weekday() {
+	case time.Saturday, time.Sunday:
+		return today
+	}
+	return today.AddDate(0, 0, int(time.Friday-today.Weekday()))
+}
+
+func addBusinessDays(day time.Time, n int) time.Time {
+	for n > 0 {
+		day = day.AddDate(0, 0, 1)
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			n--
+		}
+	}
+	return day
+}
+
+var offsetPattern = regexp.MustCompile(`^(?:in\s+)?(\d+|a|an|one|two|three|four|five|six|seven|a couple of|a few)\s+(business days?|working days?|days?|weeks?|months?)(?:\s+from\s+(?:now|today))?$`)
+
+var numberWords = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7,
+	"a couple of": 2, "a few": 3,
+}
+
+func resolveOffset(phrase string, today time.Time) (time.Time, bool) {
+	// Bare "3 days" is ambiguous outside "in ..." or "... from now"
+	if !strings.HasPrefix(phrase, "in ") && !strings.HasSuffix(phrase, " from now") && !strings.HasSuffix(phrase, " from today") {
+		return time.Time{}, false
+	}
+
+	m := offsetPattern.FindStringSubmatch(phrase)
+	if m == nil {
+		return time.Time{}, false
+	}
+
+	n, ok := numberWords[m[1]]
+	if !ok {
+		n, _ = strconv.Atoi(m[1])
+	}
+
+	switch unit := m[2]; {
+	case strings.HasPrefix(unit, "business"), strings.HasPrefix(unit, "working"):
+		return addBusinessDays(today, n), true
+	case strings.HasPrefix(unit, "day"):
+		return today.AddDate(0, 0, n), true
+	case strings.HasPrefix(unit, "week"):
+		return today.AddDate(0, 0, 7*n), true
+	default:
+		return today.AddDate(0, n, 0), true
+	}
+}
+
+func resolveWeekday(phrase string, today time.Time) (time.Time, bool) {
+	next := false
+	switch {
+	case strings.HasPrefix(phrase, "next "):
+		next = true
+		phrase = strings.TrimPrefix(phrase, "next ")
+	case strings.HasPrefix(phrase, "this "):
+		phrase = strings.TrimPrefix(phrase, "this ")
+	}
+
+	day, ok := parseWeekday(phrase)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	if next {
+		// The given day in next week, weeks running monday to sunday
+		daysSinceMonday := (int(today.Weekday()) + 6) % 7
+		nextMonday := today.AddDate(0, 0, 7-daysSinceMonday)
+		return nextMonday.AddDate(0, 0, (int(day)+6)%7), true
+	}
+
+	return today.AddDate(0, 0, (int(day)-int(today.Weekday())+7)%7), true
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] || (len(s) >= 3 && strings.HasPrefix(name, s)) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+var (
+	monthDayPattern = regexp.MustCompile(`^([a-z]+)\s+(\d{1,2})(?:st|nd|rd|th)?$`)
+	dayMonthPattern = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)?\s+(?:of\s+)?([a-z]+)$`)
+)
+
+// resolveMonthDay handles "march 3" and "3rd of march", picking next year
+// once the date has passed
+func resolveMonthDay(phrase string, today time.Time) (time.Time, bool) {
+	var monthName, dayStr string
+	if m := monthDayPattern.FindStringSubmatch(phrase); m != nil {
+		monthName, dayStr = m[1], m[2]
+	} else if m := dayMonthPattern.FindStringSubmatch(phrase); m != nil {
+		dayStr, monthName = m[1], m[2]
+	} else {
+		return time.Time{}, false
+	}
+
+	month, ok := parseMonth(monthName)
+	if !ok {
+		return time.Time{}, false
+	}
+	day, _ := strconv.Atoi(dayStr)
+	if day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+
+	t := time.Date(today.Year(), month, day, 0, 0, 0, 0, today.Location())
+	if t.Month() != month {
+		return time.Time{}, false // e.g. february 30
+	}
+	if t.Before(today) {
+		t = t.AddDate(1, 0, 0)
+	}
+	return t, true
+}
+
+func parseMonth(s string) (time.Month, bool) {
+	if len(s) < 3 {
+		return 0, false
+	}
+	for m := time.January; m <= time.December; m++ {
+		if strings.HasPrefix(strings.ToLower(m.String()), s) {
+			return m, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,257 @@
+package dates
+
+import (
+	"testing"
+	"time"
+)
+
+// now is a friday afternoon late in the month
+var now = time.Date(2026, 10, 30, 14, 0, 0, 0, time.UTC)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestResolve(t *testing.T) {
+	saturday := time.Date(2026, 10, 31, 10, 0, 0, 0, time.UTC)
+	endOfAugust := time.Date(2026, 8, 31, 9, 0, 0, 0, time.UTC)
+	endOfYear := time.Date(2026, 12, 31, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		now  time.Time
+		want time.Time
+	}{
+		{"today", now, day(2026, 10, 30)},
+		{"tomorrow", now, day(2026, 10, 31)},
+		{"yesterday", now, day(2026, 10, 29)},
+		{"day after tomorrow", now, day(2026, 11, 1)},
+		{"2026-12-01", now, day(2026, 12, 1)},
+		{"by 2026-12-01", now, day(2026, 12, 1)},
+
+		// weekdays: on the same weekday, and across into next week
+		{"friday", now, day(2026, 10, 30)},
+		{"this friday", now, day(2026, 10, 30)},
+		{"by Friday", now, day(2026, 10, 30)},
+		{"fri", now, day(2026, 10, 30)},
+		{"thursday", now, day(2026, 11, 5)},
+		{"monday", now, day(2026, 11, 2)},
+		{"next friday", now, day(2026, 11, 6)},
+		{"next monday", now, day(2026, 11, 2)},
+		{"next sunday", now, day(2026, 11, 8)},
+		{"monday", saturday, day(2026, 11, 2)},
+
+		// end of day, week and month
+		{"eod", now, day(2026, 10, 30)},
+		{"end of day", now, day(2026, 10, 30)},
+		{"cob", now, day(2026, 10, 30)},
+		{"eow", now, day(2026, 10, 30)},
+		{"end of week", saturday, day(2026, 10, 31)},
+		{"end of next week", now, day(2026, 11, 6)},
+		{"eom", now, day(2026, 10, 31)},
+		{"end of month", endOfAugust, day(2026, 8, 31)},
+		{"next week", now, day(2026, 11, 6)},
+
+		// offsets
+		{"in 3 days", now, day(2026, 11, 2)},
+		{"in a couple of days", now, day(2026, 11, 1)},
+		{"5 days from now", now, day(2026, 11, 4)},
+		{"in 2 weeks", now, day(2026, 11, 13)},
+		{"in a week", now, day(2026, 11, 6)},
+		{"in 3 months", now, day(2027, 1, 30)},
+
+		// next month from the last day of a month
+		{"next month", now, day(2026, 11, 1)},
+		{"next month", endOfAugust, day(2026, 9, 1)},
+		{"next month", endOfYear, day(2027, 1, 1)},
+		{"tomorrow", endOfYear, day(2027, 1, 1)},
+
+		// business days skip the weekend
+		{"next business day", now, day(2026, 11, 2)},
+		{"next working day", saturday, day(2026, 11, 2)},
+		{"in 1 business day", now, day(2026, 11, 2)},
+		{"in 2 business days", now, day(2026, 11, 3)},
+		{"in 5 working days", now, day(2026, 11, 6)},
+
+		// month and day, rolling over to next year once passed
+		{"november 3", now, day(2026, 11, 3)},
+		{"3rd of november", now, day(2026, 11, 3)},
+		{"march 3", now, day(2027, 3, 3)},
+		{"oct 30", now, day(2026, 10, 30)},
+		{"october 1", now, day(2027, 10, 1)},
+	}
+
+	for _, tt := range tests {
+		got, err := Resolve(tt.expr, tt.now)
+		if err != nil {
+			t.Errorf("Resolve(%q, %s): %v", tt.expr, tt.now.Format("2006-01-02"), err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Resolve(%q, %s) = %s, want %s", tt.expr, tt.now.Format("2006-01-02"), got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	for _, expr := range []string{"", "someday soon", "february 30", "3 days", "in lots of days", "the 45th"} {
+		if got, err := Resolve(expr, now); err == nil {
+			t.Errorf("Resolve(%q) = %s, want an error", expr, got.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestResolveKeepsLocation(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tz database")
+	}
+	// Late evening in UTC is already saturday in Berlin
+	got, err := Resolve("tomorrow", time.Date(2026, 10, 30, 23, 30, 0, 0, time.UTC).In(berlin))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2026, 11, 1, 0, 0, 0, 0, berlin)
+	if !got.Equal(want) || got.Location() != berlin {
+		t.Errorf("Resolve(tomorrow) = %s, want %s", got, want)
+	}
+}
+
+func TestResolvePast(t *testing.T) {
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"friday", day(2026, 10, 30)},
+		{"monday", day(2026, 10, 26)},
+		{"saturday", day(2026, 10, 24)},
+		{"last friday", day(2026, 10, 23)},
+		{"last thursday", day(2026, 10, 29)},
+		{"yesterday", day(2026, 10, 29)},
+		{"march 3", day(2026, 3, 3)},
+		{"december 25", day(2025, 12, 25)},
+		{"tomorrow", day(2026, 10, 31)},
+	}
+	for _, tt := range tests {
+		got, err := ResolvePast(tt.expr, now)
+		if err != nil {
+			t.Errorf("ResolvePast(%q): %v", tt.expr, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ResolvePast(%q) = %s, want %s", tt.expr, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestResolveDateTime(t *testing.T) {
+	tests := []struct {
+		expr     string
+		want     time.Time
+		hasClock bool
+	}{
+		{"tomorrow at 6pm", time.Date(2026, 10, 31, 18, 0, 0, 0, time.UTC), true},
+		{"friday 14:30", time.Date(2026, 10, 30, 14, 30, 0, 0, time.UTC), true},
+		{"monday @ 9:15am", time.Date(2026, 11, 2, 9, 15, 0, 0, time.UTC), true},
+		{"at 6", time.Date(2026, 10, 30, 18, 0, 0, 0, time.UTC), true},
+		{"next friday at noon", time.Date(2026, 11, 6, 12, 0, 0, 0, time.UTC), true},
+		{"2026-11-02 09:15", time.Date(2026, 11, 2, 9, 15, 0, 0, time.UTC), true},
+		{"march 3", day(2027, 3, 3), false},
+		{"tomorrow", day(2026, 10, 31), false},
+	}
+	for _, tt := range tests {
+		got, hasClock, err := ResolveDateTime(tt.expr, now)
+		if err != nil {
+			t.Errorf("ResolveDateTime(%q): %v", tt.expr, err)
+			continue
+		}
+		if !got.Equal(tt.want) || hasClock != tt.hasClock {
+			t.Errorf("ResolveDateTime(%q) = %s, %v, want %s, %v", tt.expr, got.Format("2006-01-02 15:04"), hasClock, tt.want.Format("2006-01-02 15:04"), tt.hasClock)
+		}
+	}
+
+	if _, _, err := ResolveDateTime("whenever at 6pm", now); err == nil {
+		t.Error("ResolveDateTime(whenever at 6pm): want an error")
+	}
+}
+
+func TestResolvePastDateTime(t *testing.T) {
+	tests := []struct {
+		expr     string
+		want     time.Time
+		hasClock bool
+	}{
+		{"yesterday at 4pm", time.Date(2026, 10, 29, 16, 0, 0, 0, time.UTC), true},
+		{"at 9am", time.Date(2026, 10, 30, 9, 0, 0, 0, time.UTC), true},
+		{"at 6pm", time.Date(2026, 10, 29, 18, 0, 0, 0, time.UTC), true}, // still to come today
+		{"last monday 10:00", time.Date(2026, 10, 26, 10, 0, 0, 0, time.UTC), true},
+		{"wednesday", day(2026, 10, 28), false},
+	}
+	for _, tt := range tests {
+		got, hasClock, err := ResolvePastDateTime(tt.expr, now)
+		if err != nil {
+			t.Errorf("ResolvePastDateTime(%q): %v", tt.expr, err)
+			continue
+		}
+		if !got.Equal(tt.want) || hasClock != tt.hasClock {
+			t.Errorf("ResolvePastDateTime(%q) = %s, %v, want %s, %v", tt.expr, got.Format("2006-01-02 15:04"), hasClock, tt.want.Format("2006-01-02 15:04"), tt.hasClock)
+		}
+	}
+
+	for _, expr := range []string{"tomorrow", "today at 6pm", "next week", "nonsense"} {
+		if got, _, err := ResolvePastDateTime(expr, now); err == nil {
+			t.Errorf("ResolvePastDateTime(%q) = %s, want an error", expr, got.Format("2006-01-02 15:04"))
+		}
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		in           string
+		hour, minute int
+		ok           bool
+	}{
+		{"6pm", 18, 0, true},
+		{"14:30", 14, 30, true},
+		{"9:15am", 9, 15, true},
+		{"12am", 0, 0, true},
+		{"12pm", 12, 0, true},
+		{"noon", 12, 0, true},
+		{"midnight", 0, 0, true},
+		{"6", 18, 0, true}, // a bare small hour is afternoon
+		{"8", 8, 0, true},
+		{"13pm", 0, 0, false},
+		{"25:00", 0, 0, false},
+		{"9:75", 0, 0, false},
+		{"soon", 0, 0, false},
+	}
+	for _, tt := range tests {
+		hour, minute, ok := ParseClock(tt.in)
+		if ok != tt.ok || (ok && (hour != tt.hour || minute != tt.minute)) {
+			t.Errorf("ParseClock(%q) = %d:%02d, %v, want %d:%02d, %v", tt.in, hour, minute, ok, tt.hour, tt.minute, tt.ok)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		text   string
+		want   time.Time
+		phrase string
+		ok     bool
+	}{
+		{"send the report by friday please", day(2026, 10, 30), "by friday", true},
+		{"call mom in 3 days", day(2026, 11, 2), "in 3 days", true},
+		{"review the pr by end of next week.", day(2026, 11, 6), "by end of next week", true},
+		{"ship it eom", day(2026, 10, 31), "eom", true},
+		{"nothing dated here", time.Time{}, "", false},
+		{"we sat in the sun", time.Time{}, "", false}, // short words alone aren't dates
+		{"lunch on wed", day(2026, 11, 4), "on wed", true},
+	}
+	for _, tt := range tests {
+		got, phrase, ok := Find(tt.text, now)
+		if ok != tt.ok || phrase != tt.phrase || (ok && !got.Equal(tt.want)) {
+			t.Errorf("Find(%q) = %s, %q, %v, want %s, %q, %v", tt.text, got.Format("2006-01-02"), phrase, ok, tt.want.Format("2006-01-02"), tt.phrase, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/heywinit/grechen/internal/core"
+)
+
+// EntitySource provides the people, projects and open commitments already on
+// record, so extraction reuses their IDs instead of inventing new ones
+type EntitySource interface {
+	ListPeople() ([]*core.Person, error)
+	ListProjects() ([]*core.Project, error)
+	ListOpenCommitments() ([]*core.Commitment, error)
+}
+
+// maxContextItems caps each list in the context block to keep prompts small
+const maxContextItems = 50
+
+// entities is a snapshot of an EntitySource taken once per extraction
+type entities struct {
+	people      []*core.Person
+	projects    []*core.Project
+	commitments []*core.Commitment
+}
+
+func loadEntities(src EntitySource) (*entities, error) {
+	if src == nil {
+		return &entities{}, nil
+	}
+
+	people, err := src.ListPeople()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load people: %w", err)
+	}
+	projects, err := src.ListProjects()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load projects: %w", err)
+	}
+	commitments, err := src.ListOpenCommitments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load commitments: %w", err)
+	}
+
+	return &entities{people: people, projects: projects, commitments: commitments}, nil
+}
+
+// contextBlock renders the entities as the compact block passed to the LLM
+func (e *entities) contextBlock() string {
+	var b strings.Builder
+
+	if len(e.people) > 0 {
+		b.WriteString("people (id: name):\n")
+		for i, p := range e.people {
+			if i == maxContextItems {
+				break
+			}
+			fmt.Fprintf(&b, "- %s: %s\n", p.ID, p.Name)
+		}
+	}
+
+	if len(e.projects) > 0 {
+		ids := make([]string, 0, len(e.projects))
+		for i, p := range e.projects {
+			if i == maxContextItems {
+				break
+			}
+			ids = append(ids, p.ID)
+		}
+		fmt.Fprintf(&b, "projects: %s\n", strings.Join(ids, ", "))
+	}
+
+	if len(e.commitments) > 0 {
+		b.WriteString("open commitments (commitment_id: person → description, due):\n")
+		for i, c := range e.commitments {
+			if i == maxContextItems {
+				break
+			}
+			fmt.Fprintf(&b, "- %s: %s → %s, due %s", c.ID, c.PersonID, c.Expectation.Description, c.Expectation.Deadline.Format("2006-01-02"))
+			if c.ProjectID != "" {
+				fmt.Fprintf(&b, " [%s]", c.ProjectID)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// personID maps a name the model returned onto a known person ID
+func (e *entities) personID(name string) string {
+	for _, p := range e.people {
+		if strings.EqualFold(p.ID, name) || strings.EqualFold(p.Name, name) {
+			return p.ID
+		}
+	}
+	return name
+}
+
+// projectID maps a name the model returned onto a known project ID
+func (e *entities) projectID(name string) string {
+	for _, p := range e.projects {
+		if strings.EqualFold(p.ID, name) {
+			return p.ID
+		}
+	}
+	return name
+}
+
+// normalize rewrites person and project references in a candidate to the
+// known IDs, catching "Deep" vs "deep" even when the model ignores the context
+func (e *entities) normalize(candidate *core.Candidate) {
+	if candidate.Data == nil {
+		return
+	}
+	if person, ok := candidate.Data["person"].(string); ok && person != "" {
+		candidate.Data["person"] = e.personID(person)
+	}
+	if project, ok := candidate.Data["project"].(string); ok && project != "" {
+		candidate.Data["project"] = e.projectID(project)
+	}
+
+	// Event attendees are people too
+	switch attendees := candidate.Data["attendees"].(type) {
+	case []any:
+		for i, a := range attendees {
+			if name, ok := a.(string); ok && name != "" {
+				attendees[i] = e.personID(name)
+			}
+		}
+	case []string:
+		for i, name := range attendees {
+			if name != "" {
+				attendees[i] = e.personID(name)
+			}
+		}
+	}
+}
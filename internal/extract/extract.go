@@ -1,12 +1,19 @@
 package extract
 
 import (
+	"time"
+
 	"github.com/heywinit/grechen/internal/core"
 )
 
-// Extractor parses natural language input into structured candidates
+// Extractor parses natural language input into structured candidates.
+// Relative dates in the input are resolved against now, in now's time zone.
 type Extractor interface {
-	Extract(input string) ([]core.Candidate, []core.Question, error)
+	Extract(input string, now time.Time) ([]core.Candidate, []core.Question, error)
+
+	// Name identifies what produced the last extraction, e.g.
+	// "gemini/gemini-2.5-flash" or "offline"
+	Name() string
 }
 
 const (
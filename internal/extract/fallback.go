@@ -0,0 +1,51 @@
+package extract
+
+import (
+	"errors"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+)
+
+// FallbackExtractor uses the primary extractor and switches to the fallback
+// when the primary's provider is unavailable (network down, key expired)
+type FallbackExtractor struct {
+	primary    Extractor
+	fallback   Extractor
+	onFallback func(err error)
+	used       Extractor // the extractor that handled the last input
+}
+
+// NewFallbackExtractor wraps primary with fallback. onFallback, if set, is
+// called with the provider error whenever the fallback is used.
+func NewFallbackExtractor(primary, fallback Extractor, onFallback func(err error)) *FallbackExtractor {
+	return &FallbackExtractor{
+		primary:    primary,
+		fallback:   fallback,
+		onFallback: onFallback,
+		used:       primary,
+	}
+}
+
+// Name names whichever extractor handled the last input
+func (e *FallbackExtractor) Name() string {
+	return e.used.Name()
+}
+
+func (e *FallbackExtractor) Extract(input string, now time.Time) ([]core.Candidate, []core.Question, error) {
+	e.used = e.primary
+	candidates, questions, err := e.primary.Extract(input, now)
+
+	// Only fall back when the provider itself failed; a bad answer from a
+	// working provider should surface, not be papered over
+	var providerErr *ProviderError
+	if err == nil || !errors.As(err, &providerErr) {
+		return candidates, questions, err
+	}
+
+	if e.onFallback != nil {
+		e.onFallback(err)
+	}
+	e.used = e.fallback
+	return e.fallback.Extract(input, now)
+}
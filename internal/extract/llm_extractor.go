@@ -11,31 +11,93 @@ import (
 // LLMExtractor uses an LLM provider to extract candidates
 type LLMExtractor struct {
 	provider llm.Provider
+	entities EntitySource
 }
 
-func NewLLMExtractor(p llm.Provider) *LLMExtractor {
-	return &LLMExtractor{provider: p}
+// NewLLMExtractor creates an extractor. entities may be nil, in which case the
+// model gets no context about existing people, projects or commitments.
+func NewLLMExtractor(p llm.Provider, entities EntitySource) *LLMExtractor {
+	return &LLMExtractor{provider: p, entities: entities}
 }
 
-func (e *LLMExtractor) Extract(input string) ([]core.Candidate, []core.Question, error) {
+func (e *LLMExtractor) Name() string {
+	return e.provider.Name()
+}
+
+func (e *LLMExtractor) Extract(input string, now time.Time) ([]core.Candidate, []core.Question, error) {
+	known, err := loadEntities(e.entities)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Get JSON from LLM (pass current time for date calculations)
-	jsonData, err := e.provider.ExtractJSON(input, time.Now())
+	jsonData, err := e.provider.ExtractJSON(input, now, known.contextBlock())
 	if err != nil {
-		return nil, nil, fmt.Errorf("llm extraction failed: %w", err)
+		return nil, nil, &ProviderError{Err: err}
 	}
 
-	// Validate and parse
-	candidate, err := ValidateCandidate(jsonData)
+	// Parse and validate
+	candidates, err := ParseCandidates(jsonData)
 	if err != nil {
-		// Include raw JSON in error for debugging
-		jsonStr := string(jsonData)
-		jsonPreview := jsonStr
-		if len(jsonPreview) > 500 {
-			jsonPreview = jsonPreview[:500] + "... (truncated)"
+		return nil, nil, fmt.Errorf("validation failed: %w\n  input: %q\n  extracted JSON (%d bytes): %s", err, input, len(jsonData), previewJSON(jsonData))
+	}
+
+	var valid []core.Candidate
+	var questions []core.Question
+	var firstErr error
+	for i, candidate := range candidates {
+		// The model hands back date phrases as written, resolve them here so
+		// deadlines don't depend on the model's arithmetic
+		ResolveDates(&candidate, now)
+
+		if err := ValidateCandidate(candidate); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("candidate %d: %w", i+1, err)
+			}
+			// When other parts of the input made it through, keep the text of
+			// this one as a plain log rather than dropping it
+			if len(candidates) > 1 && candidate.Text != "" {
+				valid = append(valid, core.Candidate{
+					Type:       core.IntentLog,
+					Confidence: candidate.Confidence,
+					Text:       candidate.Text,
+					Data:       map[string]any{"text": candidate.Text},
+				})
+			}
+			continue
 		}
-		return nil, nil, fmt.Errorf("validation failed: %w\n  input: %q\n  extracted JSON (%d bytes): %s", err, input, len(jsonData), jsonPreview)
+		known.normalize(&candidate)
+		valid = append(valid, candidate)
+		questions = append(questions, candidate.Questions...)
+	}
+
+	if len(valid) == 0 {
+		return nil, nil, fmt.Errorf("validation failed: %w\n  input: %q\n  extracted JSON (%d bytes): %s", firstErr, input, len(jsonData), previewJSON(jsonData))
 	}
 
-	// Return candidate and any questions
-	return []core.Candidate{*candidate}, candidate.Questions, nil
+	// Return candidates and any questions
+	return valid, questions, nil
+}
+
+// previewJSON shortens raw JSON for error messages
+func previewJSON(jsonData []byte) string {
+	jsonPreview := string(jsonData)
+	if len(jsonPreview) > 500 {
+		jsonPreview = jsonPreview[:500] + "... (truncated)"
+	}
+	return jsonPreview
+}
+
+// ProviderError means the LLM provider could not be reached or refused the
+// request, as opposed to answering with something unusable
+type ProviderError struct {
+	Err error
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("llm extraction failed: %v", e.Err)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
 }
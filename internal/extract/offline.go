@@ -0,0 +1,459 @@
+package extract
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/dates"
+)
+
+// OfflineExtractor recognises intents with keyword and grammar rules.
+// It never touches the network, so it works without an API key and gives
+// the same answer for the same input every time.
+type OfflineExtractor struct{}
+
+// NewOfflineExtractor creates an extractor
+func NewOfflineExtractor() *OfflineExtractor {
+	return &OfflineExtractor{}
+}
+
+func (e *OfflineExtractor) Name() string {
+	return "offline"
+}
+
+func (e *OfflineExtractor) Extract(input string, now time.Time) ([]core.Candidate, []core.Question, error) {
+	var candidates []core.Candidate
+	var questions []core.Question
+	logStart := -1
+	logEnd := -1
+
+	// Each clause is classified on its own. Runs of plain log clauses are
+	// merged back so "had lunch. gonna sit for working" stays one log line.
+	flushLog := func() {
+		if logStart < 0 {
+			return
+		}
+		text := strings.TrimSpace(input[logStart:logEnd])
+		candidate := core.Candidate{
+			Type:       core.IntentLog,
+			Confidence: 0.9,
+			Text:       text,
+			Data:       map[string]any{"text": text},
+		}
+		if phrase, _ := findOccurredAt(strings.ToLower(text)); phrase != "" {
+			candidate.Data["occurred_at"] = phrase
+		}
+		ResolveDates(&candidate, now)
+		candidates = append(candidates, candidate)
+		questions = append(questions, candidate.Questions...)
+		logStart = -1
+	}
+
+	for _, span := range splitClauses(input, now) {
+		candidate := e.extract(input[span[0]:span[1]], now)
+		ResolveDates(&candidate, now)
+		if candidate.Type == core.IntentLog {
+			if logStart < 0 {
+				logStart = span[0]
+			}
+			logEnd = span[1]
+			continue
+		}
+		flushLog()
+		candidates = append(candidates, candidate)
+		questions = append(questions, candidate.Questions...)
+	}
+	flushLog()
+
+	if len(candidates) == 0 {
+		return nil, nil, fmt.Errorf("nothing to extract")
+	}
+	return candidates, questions, nil
+}
+
+var (
+	clauseSeparator = regexp.MustCompile(`[.;!?]\s+|,\s+|\s+and then\s+`)
+	qualifierClause = regexp.MustCompile(`^(?:(?:it's|its|it is|that's|thats)\s+)?(?:an?\s+)?(?:(?:(?:hard|firm|strict|soft)\s+)?deadline|hard|firm|at the latest|no later than\b.*|must\b.*)$`)
+	dueWord         = regexp.MustCompile(`^(?:due|latest)\s+`)
+)
+
+// splitClauses returns the byte ranges of the clauses in the input
+func splitClauses(input string, now time.Time) [][2]int {
+	separators := clauseSeparator.FindAllStringIndex(input, -1)
+	var spans [][2]int
+	start := 0
+	for i, sep := range separators {
+		// "told deep, the pr is ready" is one clause; only split on a comma
+		// once there's a whole phrase on the left of it
+		if input[sep[0]] == ',' && len(strings.Fields(input[start:sep[0]])) < 3 {
+			continue
+		}
+		// "monday, not friday" is one clause too
+		if input[sep[0]] == ',' && replacedDate.MatchString(strings.ToLower(input[sep[1]:])) {
+			continue
+		}
+		// and so is "by tuesday, hard deadline": a trailing deadline or
+		// hardness belongs to the clause before it
+		if input[sep[0]] == ',' {
+			end := len(input)
+			if i+1 < len(separators) {
+				end = separators[i+1][0]
+			}
+			if qualifiesDeadline(input[sep[1]:end], now) {
+				continue
+			}
+		}
+		if strings.TrimSpace(input[start:sep[0]]) != "" {
+			spans = append(spans, [2]int{start, sep[0]})
+		}
+		start = sep[1]
+	}
+	if strings.TrimSpace(input[start:]) != "" {
+		spans = append(spans, [2]int{start, len(input)})
+	}
+	return spans
+}
+
+// qualifiesDeadline reports whether a clause only says when something is
+// due or how firmly, as in "hard deadline", "no later than friday" or
+// "friday at the latest"
+func qualifiesDeadline(clause string, now time.Time) bool {
+	clause = strings.ToLower(strings.TrimSpace(clause))
+	if qualifierClause.MatchString(clause) {
+		return true
+	}
+	clause = strings.TrimSuffix(clause, " at the latest")
+	_, err := dates.Resolve(dueWord.ReplaceAllString(clause, ""), now)
+	return err == nil
+}
+
+var (
+	correctionPrefixes = []string{"that's wrong", "thats wrong", "that was wrong", "correction", "actually,", "wrong,"}
+
+	commitmentVerbs = regexp.MustCompile(`\b(told|promised|committed to|owe|assured)\s+([a-z][\w-]*)`)
+	selfPromise     = regexp.MustCompile(`\b(i'll|i will|i'd|i would|will|gonna|going to)\b`)
+	recipient       = regexp.MustCompile(`\b(?:to|for|with)\s+([a-z][\w-]*)`)
+
+	rescheduleWords = regexp.MustCompile(`\b(push(?:ed|ing)?|postpon(?:e|ed|ing)|delay(?:ed|ing)?|slip(?:s|ped|ping)?|reschedul(?:e|ed|ing)|renegotiat(?:e|ed|ing)|bump(?:ed|ing)?|deadline)\b`)
+	deadlineKind    = regexp.MustCompile(`\b(?:hard|firm|strict|soft)\s+deadline\b`)
+	replacedDate    = regexp.MustCompile(`^(?:not|instead of|rather than)\s`)
+	replacedBefore  = regexp.MustCompile(`(?:^|\s)(?:not|instead of|rather than|from)$`)
+
+	eventKeywords  = regexp.MustCompile(`\b(meeting|meet|call|standup|sync|appointment|interview|demo|lunch with|dinner with)\b`)
+	clockTime      = regexp.MustCompile(`\b(?:at|@)\s+(\d{1,2}(?::\d{2})?\s*(?:am|pm)?|noon)\b`)
+	endTime        = regexp.MustCompile(`\b(?:until|till|til|to)\s+(\d{1,2}(?::\d{2})?\s*(?:am|pm)?|noon)\b`)
+	duration       = regexp.MustCompile(`\bfor\s+(an?|one|half an|\d+(?:\.\d+)?)\s*(hours?|hrs?|h|minutes?|mins?)\b`)
+	occurredAt     = regexp.MustCompile(`\b(?:yesterday|last\s+(?:monday|tuesday|wednesday|thursday|friday|saturday|sunday))(?:\s+(?:at|@)\s*(?:\d{1,2}(?::\d{2})?\s*(?:am|pm)?|noon)\b)?`)
+	completedWords = regexp.MustCompile(`\b(done|finished|completed|shipped|merged|fixed|delivered|sent)\b`)
+	progressWords  = regexp.MustCompile(`\b(progress|working on|worked on|almost done|halfway|started|wip|done|finished|completed|shipped|merged|fixed)\b`)
+	projectOn      = regexp.MustCompile(`\b(?:on|for|in)\s+(?:the\s+)?([a-z0-9][\w-]*)`)
+	projectSubject = regexp.MustCompile(`^(?:the\s+)?([a-z0-9][\w-]*)(?:\s+[a-z][\w-]*)?\s+(?:is|was|are|got)\b`)
+	projectObject  = regexp.MustCompile(`\b(?:finished|completed|shipped|merged|fixed|started)\s+(?:the\s+)?([a-z0-9][\w-]*)`)
+)
+
+// stopwords are words the grammar rules pick up that can never be a person or project
+var stopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "me": true, "him": true, "her": true, "them": true,
+	"it": true, "this": true, "that": true, "my": true, "his": true, "their": true, "our": true,
+	"you": true, "i": true, "we": true, "they": true, "he": true, "she": true, "everyone": true,
+	"today": true, "tomorrow": true, "tonight": true, "now": true, "later": true, "work": true,
+	"some": true, "lunch": true, "dinner": true, "break": true, "bit": true, "while": true,
+	"monday": true, "tuesday": true, "wednesday": true, "thursday": true, "friday": true,
+	"saturday": true, "sunday": true, "next": true, "week": true, "hours": true, "minutes": true,
+}
+
+func (e *OfflineExtractor) extract(input string, now time.Time) core.Candidate {
+	text := strings.TrimSpace(input)
+	lower := strings.ToLower(text)
+
+	// When it happened is taken out before classifying, so "yesterday"
+	// isn't read as a deadline and "at 4pm" doesn't make an event
+	phrase, rest := findOccurredAt(lower)
+	candidate := e.classify(text, rest, now)
+	candidate.Text = text
+	if phrase != "" && Backdatable(candidate.Type) {
+		candidate.Data["occurred_at"] = phrase
+	}
+	return candidate
+}
+
+// findOccurredAt finds a phrase saying when something already happened
+// ("yesterday", "last friday at 4pm") and returns it along with lower
+// where it's blanked out, so offsets into the text stay the same
+func findOccurredAt(lower string) (string, string) {
+	m := occurredAt.FindStringIndex(lower)
+	if m == nil {
+		return "", lower
+	}
+	return lower[m[0]:m[1]], lower[:m[0]] + strings.Repeat(" ", m[1]-m[0]) + lower[m[1]:]
+}
+
+func (e *OfflineExtractor) classify(text, lower string, now time.Time) core.Candidate {
+
+	if c, ok := matchRenegotiation(text, lower, now); ok {
+		return c
+	}
+
+	for _, prefix := range correctionPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return core.Candidate{
+				Type:       core.IntentCorrection,
+				Confidence: 0.9,
+				Data:       map[string]any{"text": text},
+			}
+		}
+	}
+
+	if c, ok := matchCommitment(text, lower, now); ok {
+		return c
+	}
+	if c, ok := matchEvent(text, lower, now); ok {
+		return c
+	}
+	if c, ok := matchProgress(text, lower); ok {
+		return c
+	}
+
+	return core.Candidate{
+		Type:       core.IntentLog,
+		Confidence: 0.9,
+		Data:       map[string]any{"text": text},
+	}
+}
+
+func matchCommitment(text, lower string, now time.Time) (core.Candidate, bool) {
+	var person string
+	description := text
+
+	if m := commitmentVerbs.FindStringSubmatchIndex(lower); m != nil {
+		if name := lower[m[4]:m[5]]; !stopwords[name] {
+			person = name
+		}
+		description = strings.TrimSpace(text[m[1]:])
+		description = strings.TrimPrefix(description, "that ")
+	} else if _, _, hasDate := dates.Find(lower, now); !selfPromise.MatchString(lower) || !hasDate {
+		// A bare "will" without a deadline is just a plan, not a commitment
+		return core.Candidate{}, false
+	}
+
+	if person == "" {
+		if m := recipient.FindStringSubmatch(lower); m != nil && !stopwords[m[1]] {
+			person = m[1]
+		}
+	}
+
+	expectation := map[string]any{
+		"description": description,
+		"hardness":    "soft",
+	}
+	data := map[string]any{"expectation": expectation}
+	var questions []core.Question
+
+	if person != "" {
+		data["person"] = person
+	} else {
+		questions = append(questions, core.Question{
+			ID:       "person",
+			Text:     "who is this commitment to?",
+			Required: true,
+			Field:    "person",
+		})
+	}
+
+	if _, phrase, ok := dates.Find(lower, now); ok {
+		expectation["deadline"] = phrase
+	} else {
+		questions = append(questions, core.Question{
+			ID:       "expectation_deadline",
+			Text:     "when is this due?",
+			Required: true,
+			Field:    "expectation.deadline",
+		})
+	}
+
+	if strings.Contains(lower, "hard deadline") || strings.Contains(lower, "no later than") || strings.Contains(lower, "must") {
+		expectation["hardness"] = "hard"
+	}
+
+	if project := findProject(lower); project != "" && project != person {
+		data["project"] = project
+	}
+
+	// A person with a completion word and no deadline is someone being told
+	// that an existing commitment is done
+	if person != "" && completedWords.MatchString(lower) && expectation["deadline"] == nil {
+		return core.Candidate{
+			Type:       core.IntentUpdate,
+			Confidence: 0.75,
+			Data:       map[string]any{"person": person, "status": "done"},
+		}, true
+	}
+
+	return core.Candidate{
+		Type:       core.IntentCommitment,
+		Confidence: 0.8,
+		Data:       data,
+		Questions:  questions,
+	}, true
+}
+
+// matchRenegotiation recognises a deadline moving: a reschedule word with a
+// date ("pushed the report to monday"), or a date replacing another ("it'll
+// be monday, not friday")
+func matchRenegotiation(text, lower string, now time.Time) (core.Candidate, bool) {
+	phrase, replaced := newDeadline(lower, now)
+	// "hard deadline" says how firm a deadline is, not that it moved
+	if phrase == "" || (!replaced && !rescheduleWords.MatchString(deadlineKind.ReplaceAllString(lower, ""))) {
+		return core.Candidate{}, false
+	}
+
+	data := map[string]any{"deadline": phrase}
+	var person string
+	if m := commitmentVerbs.FindStringSubmatch(lower); m != nil && !stopwords[m[2]] {
+		person = m[2]
+	} else if m := recipient.FindStringSubmatch(lower); m != nil && !stopwords[m[1]] {
+		person = m[1]
+	}
+	if person != "" {
+		data["person"] = person
+	}
+	if project := findProject(lower); project != "" && project != person {
+		data["project"] = project
+	}
+
+	return core.Candidate{
+		Type:       core.IntentRenegotiation,
+		Confidence: 0.75,
+		Data:       data,
+	}, true
+}
+
+// newDeadline finds the date a deadline is moving to, skipping dates that
+// are being replaced ("not friday", "instead of friday", "from friday").
+// replaced reports whether such a date was skipped.
+func newDeadline(lower string, now time.Time) (phrase string, replaced bool) {
+	rest := lower
+	for {
+		_, found, ok := dates.Find(rest, now)
+		if !ok {
+			return phrase, replaced
+		}
+		i := strings.Index(rest, found)
+		if i < 0 {
+			break
+		}
+		if replacedBefore.MatchString(strings.TrimSpace(rest[:i])) {
+			replaced = true
+		} else if phrase == "" {
+			phrase = found
+		}
+		rest = rest[:i] + strings.Repeat(" ", len(found)) + rest[i+len(found):]
+	}
+	return phrase, replaced
+}
+
+func matchEvent(text, lower string, now time.Time) (core.Candidate, bool) {
+	if !eventKeywords.MatchString(lower) {
+		return core.Candidate{}, false
+	}
+
+	m := clockTime.FindStringSubmatch(lower)
+	if m == nil {
+		return core.Candidate{}, false
+	}
+
+	phrase := "at " + m[1]
+	if _, day, ok := dates.Find(lower, now); ok {
+		phrase = day + " " + phrase
+	}
+
+	data := map[string]any{
+		"time":  phrase,
+		"title": text,
+	}
+	if m := recipient.FindStringSubmatch(lower); m != nil && !stopwords[m[1]] {
+		data["person"] = m[1]
+	}
+	if m := endTime.FindStringSubmatch(lower); m != nil {
+		data["end"] = m[1]
+	} else if minutes, ok := durationMinutes(lower); ok {
+		data["duration"] = minutes
+	}
+
+	return core.Candidate{
+		Type:       core.IntentEvent,
+		Confidence: 0.8,
+		Data:       data,
+	}, true
+}
+
+// durationMinutes reads how long an event lasts, as in "for an hour" or
+// "for 30 min"
+func durationMinutes(lower string) (int, bool) {
+	m := duration.FindStringSubmatch(lower)
+	if m == nil {
+		return 0, false
+	}
+
+	var n float64
+	switch m[1] {
+	case "a", "an", "one":
+		n = 1
+	case "half an":
+		n = 0.5
+	default:
+		n, _ = strconv.ParseFloat(m[1], 64)
+	}
+	if strings.HasPrefix(m[2], "h") {
+		n *= 60
+	}
+	if n <= 0 {
+		return 0, false
+	}
+	return int(n), true
+}
+
+func matchProgress(text, lower string) (core.Candidate, bool) {
+	if !progressWords.MatchString(lower) {
+		return core.Candidate{}, false
+	}
+
+	project := findProject(lower)
+	if project == "" {
+		// Progress without a project is just a log line
+		return core.Candidate{}, false
+	}
+
+	status := "in progress"
+	if completedWords.MatchString(lower) && !strings.Contains(lower, "almost") {
+		status = "done"
+	}
+
+	return core.Candidate{
+		Type:       core.IntentProgress,
+		Confidence: 0.75,
+		Data: map[string]any{
+			"project": project,
+			"status":  status,
+			"notes":   text,
+		},
+	}, true
+}
+
+func findProject(lower string) string {
+	for _, re := range []*regexp.Regexp{projectOn, projectObject, projectSubject} {
+		m := re.FindStringSubmatch(lower)
+		if m == nil || stopwords[m[1]] {
+			continue
+		}
+		if _, err := strconv.Atoi(m[1]); err == nil {
+			continue
+		}
+		return m[1]
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package extract
+
+import (
+	"testing"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+)
+
+// now is a friday morning
+var now = time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+// want is what a test expects of one candidate; empty fields aren't checked
+type want struct {
+	intent   core.IntentType
+	person   string
+	deadline string
+	hardness string
+	time     string
+}
+
+func TestOfflineExtract(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []want
+	}{
+		{"had lunch", []want{{intent: core.IntentLog}}},
+		{"told bob I'd send the report by tuesday", []want{
+			{intent: core.IntentCommitment, person: "bob", deadline: "2026-10-20", hardness: "soft"},
+		}},
+		{"meeting with alice tomorrow at 3pm", []want{
+			{intent: core.IntentEvent, person: "alice", time: "2026-10-17 15:00"},
+		}},
+
+		// a trailing deadline or hardness stays with its clause
+		{"told bob I'd send the report by tuesday, hard deadline", []want{
+			{intent: core.IntentCommitment, person: "bob", deadline: "2026-10-20", hardness: "hard"},
+		}},
+		{"promised alice the slides, no later than monday", []want{
+			{intent: core.IntentCommitment, person: "alice", deadline: "2026-10-19", hardness: "hard"},
+		}},
+		{"told bob I'd review the pr, by tuesday at the latest", []want{
+			{intent: core.IntentCommitment, person: "bob", deadline: "2026-10-20"},
+		}},
+		{"told bob I'd send the report by tuesday, had lunch after", []want{
+			{intent: core.IntentCommitment, person: "bob"},
+			{intent: core.IntentLog},
+		}},
+
+		// deadlines are resolved against when a backdated input happened
+		{"yesterday I promised alice the report by tomorrow", []want{
+			{intent: core.IntentCommitment, person: "alice", deadline: "2026-10-16"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			candidates, _, err := NewOfflineExtractor().Extract(tt.input, now)
+			if err != nil {
+				t.Fatalf("Extract: %v", err)
+			}
+			if len(candidates) != len(tt.want) {
+				t.Fatalf("got %d candidates %+v, want %d", len(candidates), candidates, len(tt.want))
+			}
+			for i, w := range tt.want {
+				checkCandidate(t, candidates[i], w)
+			}
+		})
+	}
+}
+
+func checkCandidate(t *testing.T, c core.Candidate, w want) {
+	t.Helper()
+	if c.Type != w.intent {
+		t.Errorf("type = %s, want %s (%+v)", c.Type, w.intent, c)
+	}
+	expectation, _ := c.Data["expectation"].(map[string]any)
+	checks := []struct {
+		field string
+		got   any
+		want  string
+	}{
+		{"person", c.Data["person"], w.person},
+		{"deadline", expectation["deadline"], w.deadline},
+		{"hardness", expectation["hardness"], w.hardness},
+		{"time", c.Data["time"], w.time},
+	}
+	for _, check := range checks {
+		if check.want != "" && check.got != check.want {
+			t.Errorf("%s = %v, want %s", check.field, check.got, check.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/dates"
+)
+
+// ResolveDates replaces the raw date phrases in a candidate ("friday",
+// "tomorrow at 6pm") with concrete dates anchored on now, or on when the
+// candidate happened if it says. The phrase is kept next to the resolved
+// value. Phrases that can't be resolved are removed and turned into a
+// question instead.
+func ResolveDates(candidate *core.Candidate, now time.Time) {
+	resolveOccurredAt(candidate, now)
+	// "yesterday I promised the report by tomorrow" is due today
+	now = occurredTime(candidate, now)
+
+	switch candidate.Type {
+	case core.IntentCommitment:
+		exp, ok := candidate.Data["expectation"].(map[string]any)
+		if !ok {
+			return
+		}
+		phrase, _ := exp["deadline"].(string)
+		if phrase == "" {
+			return
+		}
+		deadline, err := dates.Resolve(phrase, now)
+		if err != nil {
+			delete(exp, "deadline")
+			candidate.Questions = append(candidate.Questions, core.Question{
+				ID:       "expectation_deadline",
+				Text:     fmt.Sprintf("when is this due? (couldn't read %q)", phrase),
+				Required: true,
+				Field:    "expectation.deadline",
+			})
+			return
+		}
+		exp["deadline"] = deadline.Format("2006-01-02")
+		exp["deadline_phrase"] = phrase
+
+	case core.IntentRenegotiation:
+		phrase, _ := candidate.Data["deadline"].(string)
+		if phrase == "" {
+			return
+		}
+		deadline, err := dates.Resolve(phrase, now)
+		if err != nil {
+			delete(candidate.Data, "deadline")
+			candidate.Questions = append(candidate.Questions, core.Question{
+				ID:       "deadline",
+				Text:     fmt.Sprintf("what's the new deadline? (couldn't read %q)", phrase),
+				Required: true,
+				Field:    "deadline",
+			})
+			return
+		}
+		candidate.Data["deadline"] = deadline.Format("2006-01-02")
+		candidate.Data["deadline_phrase"] = phrase
+
+	case core.IntentEvent:
+		phrase, _ := candidate.Data["time"].(string)
+		if phrase == "" {
+			return
+		}
+		at, hasClock, err := dates.ResolveDateTime(phrase, now)
+		if err != nil {
+			delete(candidate.Data, "time")
+			candidate.Questions = append(candidate.Questions, core.Question{
+				ID:       "time",
+				Text:     fmt.Sprintf("when is this event? (couldn't read %q)", phrase),
+				Required: true,
+				Field:    "time",
+			})
+			return
+		}
+		if hasClock {
+			candidate.Data["time"] = at.Format("2006-01-02 15:04")
+		} else {
+			candidate.Data["time"] = at.Format("2006-01-02")
+		}
+		candidate.Data["time_phrase"] = phrase
+		resolveEventEnd(candidate, at, hasClock, now)
+	}
+}
+
+// resolveEventEnd resolves an event's "end" phrase. A bare time of day
+// ("7pm") is on the day the event starts; an end that can't be read, or
+// one for an event without a time of day, is dropped.
+func resolveEventEnd(candidate *core.Candidate, start time.Time, hasClock bool, now time.Time) {
+	phrase, _ := candidate.Data["end"].(string)
+	if phrase == "" {
+		return
+	}
+	delete(candidate.Data, "end")
+	if !hasClock {
+		return
+	}
+
+	var end time.Time
+	if hour, minute, ok := dates.ParseClock(strings.TrimSpace(strings.ToLower(phrase))); ok {
+		end = time.Date(start.Year(), start.Month(), start.Day(), hour, minute, 0, 0, start.Location())
+	} else if at, endClock, err := dates.ResolveDateTime(phrase, now); err == nil && endClock {
+		end = at
+	} else {
+		return
+	}
+	candidate.Data["end"] = end.Format("2006-01-02 15:04")
+	candidate.Data["end_phrase"] = phrase
+}
+
+// Backdatable reports whether a candidate of type t can say when what it
+// describes happened: logs, progress and commitments made earlier
+func Backdatable(t core.IntentType) bool {
+	return t == core.IntentLog || t == core.IntentProgress || t == core.IntentCommitment
+}
+
+// resolveOccurredAt resolves the "occurred_at" phrase of a candidate
+// ("yesterday at 4pm") to when it happened: "2006-01-02 15:04", or just the
+// date when no time of day was given. Candidates that can't be backdated
+// lose the phrase.
+func resolveOccurredAt(candidate *core.Candidate, now time.Time) {
+	phrase, _ := candidate.Data["occurred_at"].(string)
+	if phrase == "" {
+		return
+	}
+	if !Backdatable(candidate.Type) {
+		delete(candidate.Data, "occurred_at")
+		return
+	}
+
+	at, hasClock, err := dates.ResolvePastDateTime(phrase, now)
+	if err != nil {
+		delete(candidate.Data, "occurred_at")
+		candidate.Questions = append(candidate.Questions, core.Question{
+			ID:    "occurred_at",
+			Text:  fmt.Sprintf("when did this happen? (couldn't place %q in the past)", phrase),
+			Field: "occurred_at",
+		})
+		return
+	}
+	if hasClock {
+		candidate.Data["occurred_at"] = at.Format("2006-01-02 15:04")
+	} else {
+		candidate.Data["occurred_at"] = at.Format("2006-01-02")
+	}
+	candidate.Data["occurred_at_phrase"] = phrase
+}
+
+// occurredTime is when a candidate with a resolved "occurred_at" happened, or
+// now if it doesn't say. A date without a time of day keeps now's clock.
+func occurredTime(candidate *core.Candidate, now time.Time) time.Time {
+	value, _ := candidate.Data["occurred_at"].(string)
+	if value == "" {
+		return now
+	}
+	if at, err := time.ParseInLocation("2006-01-02 15:04", value, now.Location()); err == nil {
+		return at
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, now.Location())
+	if err != nil {
+		return now
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), now.Hour(), now.Minute(), now.Second(), 0, now.Location())
+}
+
+// Unresolve puts back the phrases ResolveDates resolved, so the candidate
+// can be resolved again against a different time
+func Unresolve(candidate *core.Candidate) {
+	restore := func(data map[string]any, key string) {
+		if phrase, ok := data[key+"_phrase"].(string); ok {
+			data[key] = phrase
+			delete(data, key+"_phrase")
+		}
+	}
+	if exp, ok := candidate.Data["expectation"].(map[string]any); ok {
+		restore(exp, "deadline")
+	}
+	for _, key := range []string{"occurred_at", "deadline", "time", "end"} {
+		restore(candidate.Data, key)
+	}
+}
@@ -8,10 +8,13 @@ import (
 	"github.com/heywinit/grechen/internal/core"
 )
 
-// ValidateCandidate validates and filters candidates based on confidence and schema
-func ValidateCandidate(rawJSON []byte) (*core.Candidate, error) {
-	var candidate core.Candidate
-	if err := json.Unmarshal(rawJSON, &candidate); err != nil {
+// ParseCandidates parses the extraction JSON into candidates. It accepts the
+// {"candidates": [...]} envelope as well as a single bare candidate object.
+func ParseCandidates(rawJSON []byte) ([]core.Candidate, error) {
+	var envelope struct {
+		Candidates []core.Candidate `json:"candidates"`
+	}
+	if err := json.Unmarshal(rawJSON, &envelope); err != nil {
 		jsonStr := string(rawJSON)
 		// Show more context for debugging
 		displayStr := jsonStr
@@ -20,31 +23,47 @@ func ValidateCandidate(rawJSON []byte) (*core.Candidate, error) {
 		}
 		return nil, fmt.Errorf("invalid JSON: %w\n  received: %q", err, displayStr)
 	}
+	if envelope.Candidates != nil {
+		if len(envelope.Candidates) == 0 {
+			return nil, fmt.Errorf("no candidates in response")
+		}
+		return envelope.Candidates, nil
+	}
 
+	var candidate core.Candidate
+	if err := json.Unmarshal(rawJSON, &candidate); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return []core.Candidate{candidate}, nil
+}
+
+// ValidateCandidate validates a candidate based on confidence and schema
+func ValidateCandidate(candidate core.Candidate) error {
 	// Check confidence threshold
 	if candidate.Confidence < MinConfidence {
-		return nil, fmt.Errorf("confidence too low: %.2f < %.2f", candidate.Confidence, MinConfidence)
+		return fmt.Errorf("confidence too low: %.2f < %.2f", candidate.Confidence, MinConfidence)
 	}
 
 	// Validate intent type
 	validTypes := map[core.IntentType]bool{
-		core.IntentLog:        true,
-		core.IntentProgress:   true,
-		core.IntentCommitment: true,
-		core.IntentUpdate:     true,
-		core.IntentEvent:      true,
-		core.IntentCorrection: true,
+		core.IntentLog:           true,
+		core.IntentProgress:      true,
+		core.IntentCommitment:    true,
+		core.IntentUpdate:        true,
+		core.IntentEvent:         true,
+		core.IntentCorrection:    true,
+		core.IntentRenegotiation: true,
 	}
 	if !validTypes[candidate.Type] {
-		return nil, fmt.Errorf("invalid intent type: %s", candidate.Type)
+		return fmt.Errorf("invalid intent type: %s", candidate.Type)
 	}
 
 	// Validate data structure based on type
 	if err := validateCandidateData(candidate.Type, candidate.Data); err != nil {
-		return nil, fmt.Errorf("invalid data structure: %w", err)
+		return fmt.Errorf("invalid data structure: %w", err)
 	}
 
-	return &candidate, nil
+	return nil
 }
 
 func validateCandidateData(intentType core.IntentType, data map[string]any) error {
@@ -57,6 +76,8 @@ func validateCandidateData(intentType core.IntentType, data map[string]any) erro
 		return validateEventData(data)
 	case core.IntentProgress:
 		return validateProgressData(data)
+	case core.IntentRenegotiation:
+		return validateRenegotiationData(data)
 	case core.IntentLog, core.IntentCorrection:
 		// These types have minimal structure requirements
 		return nil
@@ -83,17 +104,17 @@ func validateCommitmentData(data map[string]any) error {
 	if _, ok := expRaw["description"]; !ok {
 		return fmt.Errorf("expectation missing description")
 	}
-	if _, ok := expRaw["deadline"]; !ok {
-		return fmt.Errorf("expectation missing deadline")
-	}
 
-	// Validate deadline format
-	deadlineStr, ok := expRaw["deadline"].(string)
-	if !ok {
-		return fmt.Errorf("deadline must be a string")
-	}
-	if _, err := time.Parse("2006-01-02", deadlineStr); err != nil {
-		return fmt.Errorf("invalid deadline format: %w", err)
+	// A missing deadline is asked for later; one that's present must be a
+	// resolved date
+	if raw, ok := expRaw["deadline"]; ok {
+		deadlineStr, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("deadline must be a string")
+		}
+		if _, err := time.Parse("2006-01-02", deadlineStr); err != nil {
+			return fmt.Errorf("invalid deadline format: %w", err)
+		}
 	}
 
 	// Validate hardness if present
@@ -118,6 +139,21 @@ func validateUpdateData(data map[string]any) error {
 	return nil
 }
 
+func validateRenegotiationData(data map[string]any) error {
+	// A missing deadline is asked for later; one that's present must be a
+	// resolved date
+	if raw, ok := data["deadline"]; ok {
+		deadlineStr, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("deadline must be a string")
+		}
+		if _, err := time.Parse("2006-01-02", deadlineStr); err != nil {
+			return fmt.Errorf("invalid deadline format: %w", err)
+		}
+	}
+	return nil
+}
+
 func validateEventData(data map[string]any) error {
 	if _, ok := data["time"]; !ok {
 		return fmt.Errorf("event missing time")
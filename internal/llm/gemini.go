@@ -7,7 +7,6 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 )
 
@@ -35,9 +34,13 @@ func NewGeminiProvider() (*GeminiProvider, error) {
 	}, nil
 }
 
-func (g *GeminiProvider) ExtractJSON(input string, now time.Time) ([]byte, error) {
+func (g *GeminiProvider) Name() string {
+	return "gemini/" + g.model
+}
+
+func (g *GeminiProvider) ExtractJSON(input string, now time.Time, known string) ([]byte, error) {
 	// Build the prompt for structured extraction
-	prompt := buildGeminiExtractionPrompt(input, now)
+	prompt := buildExtractionPrompt(input, now, known)
 
 	// Prepare the request body
 	reqBody := map[string]any{
@@ -110,125 +113,5 @@ func (g *GeminiProvider) ExtractJSON(input string, now time.Time) ([]byte, error
 	}
 
 	// Extract JSON from response
-	content := geminiResp.Candidates[0].Content.Parts[0].Text
-	rawContent := content // Keep original for error messages
-	
-	// Remove markdown code blocks if present
-	contentBytes := []byte(content)
-	contentBytes = bytes.TrimSpace(contentBytes)
-	if bytes.HasPrefix(contentBytes, []byte("```json")) {
-		contentBytes = bytes.TrimPrefix(contentBytes, []byte("```json"))
-		contentBytes = bytes.TrimSuffix(contentBytes, []byte("```"))
-	} else if bytes.HasPrefix(contentBytes, []byte("```")) {
-		contentBytes = bytes.TrimPrefix(contentBytes, []byte("```"))
-		contentBytes = bytes.TrimSuffix(contentBytes, []byte("```"))
-	}
-	contentBytes = bytes.TrimSpace(contentBytes)
-	
-	// Try to extract JSON if wrapped in text
-	// Use balanced brace matching for more robust extraction
-	contentStr := string(contentBytes)
-	startIdx := strings.Index(contentStr, "{")
-	if startIdx >= 0 {
-		// Find matching closing brace by counting braces
-		braceCount := 0
-		endIdx := -1
-		for i := startIdx; i < len(contentStr); i++ {
-			if contentStr[i] == '{' {
-				braceCount++
-			} else if contentStr[i] == '}' {
-				braceCount--
-				if braceCount == 0 {
-					endIdx = i
-					break
-				}
-			}
-		}
-		if endIdx > startIdx {
-			contentBytes = []byte(contentStr[startIdx : endIdx+1])
-		} else {
-			// JSON appears incomplete - return error with full context
-			rawContentStr := rawContent
-			if len(rawContentStr) > 1000 {
-				rawContentStr = rawContentStr[:1000] + "... (truncated for display)"
-			}
-			return nil, fmt.Errorf("incomplete JSON response (unmatched braces, found %d open)\n  extracted so far: %q\n  full response: %q", braceCount, string(contentBytes[startIdx:]), rawContentStr)
-		}
-	}
-	
-	// Validate that we have some content
-	if len(contentBytes) == 0 {
-		rawContentStr := rawContent
-		if len(rawContentStr) > 1000 {
-			rawContentStr = rawContentStr[:1000] + "... (truncated for display)"
-		}
-		return nil, fmt.Errorf("no JSON content extracted from LLM response\n  raw response: %q", rawContentStr)
-	}
-	
-	// Validate JSON is complete by attempting to parse it
-	var testJSON map[string]any
-	if err := json.Unmarshal(contentBytes, &testJSON); err != nil {
-		rawContentStr := rawContent
-		if len(rawContentStr) > 1000 {
-			rawContentStr = rawContentStr[:1000] + "... (truncated for display)"
-		}
-		return nil, fmt.Errorf("extracted JSON is invalid: %w\n  raw response: %q", err, rawContentStr)
-	}
-	
-	return contentBytes, nil
-}
-
-func buildGeminiExtractionPrompt(input string, now time.Time) string {
-	today := now.Format("2006-01-02")
-	dayOfWeek := now.Format("Monday")
-	currentTime := now.Format("15:04")
-	dateReadable := now.Format("January 2, 2006")
-	tomorrow := now.AddDate(0, 0, 1).Format("2006-01-02")
-	nextWeek := now.AddDate(0, 0, 7).Format("2006-01-02")
-	
-	return fmt.Sprintf(`You are a structured data extraction assistant for a personal task management system. Extract information from the user's natural language input and return ONLY valid JSON.
-
-Current context:
-- Date: %s (%s)
-- Day of week: %s
-- Current time: %s
-- Date (readable): %s
-
-Input: "%s"
-
-Return a JSON object with this exact structure:
-{
-  "type": "log" | "progress" | "commitment" | "update" | "event" | "correction",
-  "confidence": 0.0-1.0,
-  "data": {
-    // Fields depend on type:
-    // - commitment: { "person": string, "project": string (optional), "expectation": { "description": string, "deadline": "YYYY-MM-DD", "hardness": "hard"|"soft" } }
-    // - progress: { "project": string, "status": string (optional), "notes": string (optional) }
-    // - update: { "commitment_id": string (optional), "person": string (optional), "project": string (optional), "status": string }
-    // - event: { "time": "YYYY-MM-DD HH:MM" or "YYYY-MM-DD", "person": string (optional), "project": string (optional), "title": string }
-    // - log: { "text": string }
-    // - correction: { "text": string }
-  },
-  "questions": [] // Array of questions if information is missing/ambiguous
-}
-
-Guidelines:
-- If it's a commitment (told someone, promised, will do, said I'll), use type "commitment"
-- If it's progress update (done, finished, completed, made progress), use type "progress" or "update"
-- If it's scheduling (meet, call, event, appointment), use type "event"
-- If it's a correction (that's wrong, actually, correction), use type "correction"
-- Otherwise, use type "log"
-- Extract dates relative to today (%s, %s) if needed:
-  * "today" = %s
-  * "tomorrow" = %s
-  * "next week" = %s
-  * "next few hours" or "later today" = %s (same day, use current time %s to determine if still possible)
-  * "this week" = calculate based on current day (%s) - week runs Monday-Sunday
-  * "next Monday/Tuesday/etc" = next occurrence of that day
-  * Always use YYYY-MM-DD format for dates
-- Be confident (>= 0.7) if you're sure, lower if uncertain
-- Include questions array if key info is missing (e.g., missing person, deadline, project)
-- For commitments, infer project from context if mentioned (kaifu, octomod, etc.)
-
-Return ONLY the JSON object, no other text.`, today, dayOfWeek, dayOfWeek, currentTime, dateReadable, input, today, dayOfWeek, today, tomorrow, nextWeek, today, currentTime, dayOfWeek)
+	return extractJSON(geminiResp.Candidates[0].Content.Parts[0].Text)
 }
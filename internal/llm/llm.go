@@ -7,5 +7,10 @@ type Provider interface {
 	// ExtractJSON takes natural language input and returns structured JSON
 	// The JSON should match the Candidate schema from the extract package
 	// now is the current time, used for relative date calculations
-	ExtractJSON(input string, now time.Time) ([]byte, error)
+	// known is a compact block describing existing people, projects and open
+	// commitments, so the model can reuse their IDs (may be empty)
+	ExtractJSON(input string, now time.Time, known string) ([]byte, error)
+
+	// Name identifies the provider and model, e.g. "gemini/gemini-2.5-flash"
+	Name() string
 }
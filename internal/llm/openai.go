@@ -0,0 +1,139 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	defaultOpenAIModel   = "gpt-4o-mini"
+)
+
+// OpenAIProvider implements LLM provider using the OpenAI chat completions API.
+// Any server speaking the same protocol (vLLM, llama.cpp, LM Studio, Ollama)
+// works by pointing OPENAI_BASE_URL at it.
+type OpenAIProvider struct {
+	apiKey   string
+	baseURL  string
+	client   *http.Client
+	model    string
+	jsonMode bool
+}
+
+func NewOpenAIProvider() (*OpenAIProvider, error) {
+	baseURL := strings.TrimRight(os.Getenv("OPENAI_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+
+	// Local servers usually don't need a key, the hosted API always does
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" && baseURL == defaultOpenAIBaseURL {
+		return nil, fmt.Errorf("OPENAI_API_KEY environment variable not set")
+	}
+
+	model := os.Getenv("OPENAI_MODEL")
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+
+	jsonMode := true
+	if v := os.Getenv("OPENAI_JSON_MODE"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OPENAI_JSON_MODE %q: %w", v, err)
+		}
+		jsonMode = parsed
+	}
+
+	return &OpenAIProvider{
+		apiKey:  apiKey,
+		baseURL: baseURL,
+		client: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+		model:    model,
+		jsonMode: jsonMode,
+	}, nil
+}
+
+func (o *OpenAIProvider) Name() string {
+	return "openai/" + o.model
+}
+
+func (o *OpenAIProvider) ExtractJSON(input string, now time.Time, known string) ([]byte, error) {
+	prompt := buildExtractionPrompt(input, now, known)
+
+	reqBody := map[string]any{
+		"model": o.model,
+		"messages": []map[string]string{
+			{
+				"role":    "user",
+				"content": prompt,
+			},
+		},
+		"temperature": 0.1,
+		"max_tokens":  2000,
+	}
+	if o.jsonMode {
+		reqBody["response_format"] = map[string]string{"type": "json_object"}
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", o.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("openai API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var chatResp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return nil, fmt.Errorf("no content in response")
+	}
+
+	// Check if response was truncated
+	if chatResp.Choices[0].FinishReason == "length" {
+		return nil, fmt.Errorf("response truncated due to token limit (increase max_tokens)")
+	}
+
+	return extractJSON(chatResp.Choices[0].Message.Content)
+}
@@ -0,0 +1,168 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chatRequest is the part of a chat completions request the tests look at
+type chatRequest struct {
+	Model          string            `json:"model"`
+	ResponseFormat map[string]string `json:"response_format"`
+}
+
+// newTestServer serves /v1/chat/completions with the given status and body,
+// handing each request it sees to inspect
+func newTestServer(t *testing.T, status int, body string, inspect func(r *http.Request, req chatRequest)) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("got %s %s, want POST /v1/chat/completions", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if inspect != nil {
+			inspect(r, req)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestProvider points the provider at server, with a trailing slash on
+// the base URL to check it's trimmed
+func newTestProvider(t *testing.T, server *httptest.Server, apiKey string) *OpenAIProvider {
+	t.Helper()
+	t.Setenv("OPENAI_BASE_URL", server.URL+"/v1/")
+	t.Setenv("OPENAI_API_KEY", apiKey)
+	t.Setenv("OPENAI_MODEL", "test-model")
+	t.Setenv("OPENAI_JSON_MODE", "")
+
+	provider, err := NewOpenAIProvider()
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider: %v", err)
+	}
+	return provider
+}
+
+const okResponse = `{"choices":[{"message":{"content":"{\"type\":\"log\"}"},"finish_reason":"stop"}]}`
+
+var testNow = time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+func TestOpenAIRequest(t *testing.T) {
+	var seen bool
+	server := newTestServer(t, http.StatusOK, okResponse, func(r *http.Request, req chatRequest) {
+		seen = true
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer sk-test")
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+		if req.Model != "test-model" {
+			t.Errorf("model = %q, want test-model", req.Model)
+		}
+		if req.ResponseFormat["type"] != "json_object" {
+			t.Errorf("response_format = %v, want json_object", req.ResponseFormat)
+		}
+	})
+
+	provider := newTestProvider(t, server, "sk-test")
+	got, err := provider.ExtractJSON("wrote the docs", testNow, "")
+	if err != nil {
+		t.Fatalf("ExtractJSON: %v", err)
+	}
+	if !seen {
+		t.Fatal("server saw no request")
+	}
+	if string(got) != `{"type":"log"}` {
+		t.Errorf("ExtractJSON = %s, want {\"type\":\"log\"}", got)
+	}
+	if provider.Name() != "openai/test-model" {
+		t.Errorf("Name = %q, want openai/test-model", provider.Name())
+	}
+}
+
+func TestOpenAIKeylessLocalServer(t *testing.T) {
+	server := newTestServer(t, http.StatusOK, okResponse, func(r *http.Request, req chatRequest) {
+		if _, ok := r.Header["Authorization"]; ok {
+			t.Errorf("Authorization = %q, want no header", r.Header.Get("Authorization"))
+		}
+	})
+
+	provider := newTestProvider(t, server, "")
+	if _, err := provider.ExtractJSON("wrote the docs", testNow, ""); err != nil {
+		t.Fatalf("ExtractJSON: %v", err)
+	}
+}
+
+func TestOpenAIJSONModeOff(t *testing.T) {
+	server := newTestServer(t, http.StatusOK, okResponse, func(r *http.Request, req chatRequest) {
+		if req.ResponseFormat != nil {
+			t.Errorf("response_format = %v, want none", req.ResponseFormat)
+		}
+	})
+
+	newTestProvider(t, server, "")
+	t.Setenv("OPENAI_JSON_MODE", "false")
+	provider, err := NewOpenAIProvider()
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider: %v", err)
+	}
+	if _, err := provider.ExtractJSON("wrote the docs", testNow, ""); err != nil {
+		t.Fatalf("ExtractJSON: %v", err)
+	}
+}
+
+func TestOpenAIErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"non-2xx", http.StatusUnauthorized, `{"error":{"message":"bad key"}}`, "status 401"},
+		{"server error", http.StatusInternalServerError, `upstream down`, "upstream down"},
+		{"empty choices", http.StatusOK, `{"choices":[]}`, "no content"},
+		{"truncated", http.StatusOK, `{"choices":[{"message":{"content":"{\"type\":"},"finish_reason":"length"}]}`, "truncated"},
+		{"bad json", http.StatusOK, `not json`, "failed to decode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, tt.status, tt.body, nil)
+			provider := newTestProvider(t, server, "sk-test")
+			_, err := provider.ExtractJSON("wrote the docs", testNow, "")
+			if err == nil {
+				t.Fatalf("ExtractJSON: want an error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ExtractJSON error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewOpenAIProviderNeedsKeyForHostedAPI(t *testing.T) {
+	t.Setenv("OPENAI_BASE_URL", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	if _, err := NewOpenAIProvider(); err == nil {
+		t.Error("NewOpenAIProvider: want an error without OPENAI_API_KEY for the hosted API")
+	}
+
+	t.Setenv("OPENAI_BASE_URL", "http://localhost:1")
+	t.Setenv("OPENAI_JSON_MODE", "maybe")
+	if _, err := NewOpenAIProvider(); err == nil {
+		t.Error("NewOpenAIProvider: want an error for OPENAI_JSON_MODE=maybe")
+	}
+}
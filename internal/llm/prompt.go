@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"fmt"
+	"time"
+)
+
+// buildExtractionPrompt builds the extraction prompt shared by all providers
+func buildExtractionPrompt(input string, now time.Time, known string) string {
+	today := now.Format("2006-01-02")
+	dayOfWeek := now.Format("Monday")
+	currentTime := now.Format("15:04")
+	dateReadable := now.Format("January 2, 2006")
+	if known == "" {
+		known = "(nothing recorded yet)"
+	}
+
+	return fmt.Sprintf(`You are a structured data extraction assistant for a personal task management system. Extract information from the user's natural language input and return ONLY valid JSON.
+
+Current context:
+- Date: %s (%s)
+- Day of week: %s
+- Current time: %s
+- Date (readable): %s
+
+Known entities:
+%s
+
+Input: "%s"
+
+Return a JSON object with this exact structure:
+{
+  "candidates": [
+    {
+      "type": "log" | "progress" | "commitment" | "update" | "renegotiation" | "event" | "correction",
+      "confidence": 0.0-1.0,
+      "text": string, // the part of the input this candidate was extracted from
+      "data": {
+        // Fields depend on type:
+        // - commitment: { "person": string, "project": string (optional), "expectation": { "description": string, "deadline": string, "hardness": "hard"|"soft" } }
+        // - progress: { "project": string, "status": string (optional), "notes": string (optional) }
+        // - update: { "commitment_id": string (optional), "person": string (optional), "project": string (optional), "status": string }
+        // - renegotiation: { "commitment_id": string (optional), "person": string (optional), "project": string (optional), "deadline": string }
+        // - event: { "time": string, "end": string (optional), "duration": number of minutes (optional), "attendees": [string] (optional), "project": string (optional), "title": string }
+        // - log: { "text": string }
+        // - correction: { "text": string }
+        // - log, progress and commitment may also have "occurred_at": string (optional)
+      },
+      "questions": [] // Array of questions if information is missing/ambiguous
+    }
+  ]
+}
+
+Guidelines:
+- Most inputs describe one thing and produce exactly one candidate
+- If the input describes several separate things (e.g. "finished X, told Y I'd do Z by friday, call with W at 6"), return one candidate per thing, in the order they appear
+- If it's a commitment (told someone, promised, will do, said I'll), use type "commitment"
+- If it's progress update (done, finished, completed, made progress), use type "progress" or "update"
+- If an existing commitment's deadline moves ("actually it'll be monday, not friday", "pushed the report to next week"), use type "renegotiation" with the new deadline
+- If it's scheduling (meet, call, event, appointment), use type "event"
+- If it's a correction (that's wrong, actually, correction), use type "correction"
+- Otherwise, use type "log"
+- For "deadline" (commitment expectation or renegotiation) and event "time", copy the date/time phrase exactly as the input says it, without converting it:
+  * "by friday" -> "friday", "end of week" -> "end of week", "in 3 days" -> "in 3 days"
+  * "call with mom tomorrow at 6" -> "tomorrow at 6"
+  * an event's "end" is copied the same way ("until 7pm" -> "7pm"); give "duration" instead when the input says how long ("for an hour" -> 60)
+  * Only use YYYY-MM-DD (or YYYY-MM-DD HH:MM) when the input itself gives an explicit date
+  * Leave "deadline" out if the input doesn't mention one
+- If a log, progress or commitment says when it already happened ("yesterday at 4pm I finished X", "last friday I told Y..."), copy that phrase into "occurred_at" the same way ("yesterday at 4pm"); leave it out otherwise, and never put a deadline there
+- Be confident (>= 0.7) if you're sure, lower if uncertain
+- Include questions array if key info is missing (e.g., missing person, deadline, project)
+- Reuse IDs from known entities whenever the input refers to them (match names case-insensitively, "Deep" is person "deep")
+- Only introduce a new person or project ID when nothing known matches; use a short lowercase ID
+- For updates and renegotiations, set "commitment_id" to the matching open commitment's ID when one fits
+- For commitments, infer project from context if mentioned (prefer a known project)
+
+Return ONLY the JSON object, no other text.`, today, dayOfWeek, dayOfWeek, currentTime, dateReadable, known, input)
+}
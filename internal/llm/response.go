@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// extractJSON pulls the JSON object out of a model's text response, stripping
+// markdown fences and any surrounding prose
+func extractJSON(content string) ([]byte, error) {
+	rawContent := content // Keep original for error messages
+
+	// Remove markdown code blocks if present
+	contentBytes := []byte(content)
+	contentBytes = bytes.TrimSpace(contentBytes)
+	if bytes.HasPrefix(contentBytes, []byte("```json")) {
+		contentBytes = bytes.TrimPrefix(contentBytes, []byte("```json"))
+		contentBytes = bytes.TrimSuffix(contentBytes, []byte("```"))
+	} else if bytes.HasPrefix(contentBytes, []byte("```")) {
+		contentBytes = bytes.TrimPrefix(contentBytes, []byte("```"))
+		contentBytes = bytes.TrimSuffix(contentBytes, []byte("```"))
+	}
+	contentBytes = bytes.TrimSpace(contentBytes)
+
+	// Try to extract JSON if wrapped in text
+	// Use balanced brace matching for more robust extraction
+	contentStr := string(contentBytes)
+	startIdx := strings.Index(contentStr, "{")
+	if startIdx >= 0 {
+		// Find matching closing brace by counting braces
+		braceCount := 0
+		endIdx := -1
+		for i := startIdx; i < len(contentStr); i++ {
+			if contentStr[i] == '{' {
+				braceCount++
+			} else if contentStr[i] == '}' {
+				braceCount--
+				if braceCount == 0 {
+					endIdx = i
+					break
+				}
+			}
+		}
+		if endIdx > startIdx {
+			contentBytes = []byte(contentStr[startIdx : endIdx+1])
+		} else {
+			// JSON appears incomplete - return error with full context
+			rawContentStr := rawContent
+			if len(rawContentStr) > 1000 {
+				rawContentStr = rawContentStr[:1000] + "... (truncated for display)"
+			}
+			return nil, fmt.Errorf("incomplete JSON response (unmatched braces, found %d open)\n  extracted so far: %q\n  full response: %q", braceCount, string(contentBytes[startIdx:]), rawContentStr)
+		}
+	}
+
+	// Validate that we have some content
+	if len(contentBytes) == 0 {
+		rawContentStr := rawContent
+		if len(rawContentStr) > 1000 {
+			rawContentStr = rawContentStr[:1000] + "... (truncated for display)"
+		}
+		return nil, fmt.Errorf("no JSON content extracted from LLM response\n  raw response: %q", rawContentStr)
+	}
+
+	// Validate JSON is complete by attempting to parse it
+	var testJSON map[string]any
+	if err := json.Unmarshal(contentBytes, &testJSON); err != nil {
+		rawContentStr := rawContent
+		if len(rawContentStr) > 1000 {
+			rawContentStr = rawContentStr[:1000] + "... (truncated for display)"
+		}
+		return nil, fmt.Errorf("extracted JSON is invalid: %w\n  raw response: %q", err, rawContentStr)
+	}
+
+	return contentBytes, nil
+}
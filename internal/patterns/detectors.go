@@ -146,11 +146,17 @@ func (p *Patterns) detectOptimisticStall() ([]core.Deviation, error) {
 	}
 
 	var deviations []core.Deviation
-	now := time.Now()
+	now := p.store.Now()
 
 	for _, commitment := range commitments {
 		// Check if commitment has been updated multiple times but not fulfilled
-		if commitment.Status == core.StatusUpdated && len(commitment.History) >= 2 {
+		updates := 0
+		for _, event := range commitment.History {
+			if event.Type == string(core.StatusUpdated) {
+				updates++
+			}
+		}
+		if commitment.Status == core.StatusUpdated && updates >= 2 {
 			// Check if deadline is approaching or passed
 			daysUntilDeadline := commitment.Expectation.Deadline.Sub(now).Hours() / 24
 			if daysUntilDeadline < 1 && commitment.Status != core.StatusFulfilled {
@@ -159,7 +165,7 @@ func (p *Patterns) detectOptimisticStall() ([]core.Deviation, error) {
 					Severity: "medium",
 					Question: core.Question{
 						ID:       fmt.Sprintf("optimistic_stall_%s", commitment.ID),
-						Text:     fmt.Sprintf("commitment to %s (%s) updated %d times but not fulfilled. deadline: %s. status?", commitment.PersonID, commitment.Expectation.Description, len(commitment.History), commitment.Expectation.Deadline.Format("2006-01-02")),
+						Text:     fmt.Sprintf("commitment to %s (%s) updated %d times but not fulfilled. deadline: %s. status?", commitment.PersonID, commitment.Expectation.Description, updates, commitment.Expectation.Deadline.Format("2006-01-02")),
 						Required: false,
 						Field:    "commitment_status",
 					},
@@ -9,11 +9,11 @@ import (
 )
 
 type Patterns struct {
-	store *store.Store
+	store store.Store
 	stats *stats.Stats
 }
 
-func New(s *store.Store, st *stats.Stats) *Patterns {
+func New(s store.Store, st *stats.Stats) *Patterns {
 	return &Patterns{
 		store: s,
 		stats: st,
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/store"
 )
 
 func (r *Rules) validateCommitment(candidate core.Candidate, entry *core.Entry) (*ValidationResult, error) {
@@ -19,20 +20,6 @@ func (r *Rules) validateCommitment(candidate core.Candidate, entry *core.Entry)
 			Required: true,
 			Field:    "person",
 		})
-	} else {
-		// Check if person exists, if not create placeholder
-		_, err := r.store.GetPerson(personID)
-		if err != nil {
-			// Person doesn't exist, create it
-			person := &core.Person{
-				ID:       personID,
-				Name:     personID,
-				Metadata: make(map[string]any),
-			}
-			if err := r.store.SavePerson(person); err != nil {
-				return nil, fmt.Errorf("failed to save person: %w", err)
-			}
-		}
 	}
 
 	// Extract expectation
@@ -42,7 +29,7 @@ func (r *Rules) validateCommitment(candidate core.Candidate, entry *core.Entry)
 			ID:       "expectation",
 			Text:     "what is the commitment?",
 			Required: true,
-			Field:    "expectation",
+			Field:    "expectation.description",
 		})
 	} else {
 		description, _ := expRaw["description"].(string)
@@ -68,20 +55,6 @@ func (r *Rules) validateCommitment(candidate core.Candidate, entry *core.Entry)
 
 	// Extract project (optional)
 	projectID, _ := candidate.Data["project"].(string)
-	if projectID != "" {
-		_, err := r.store.GetProject(projectID)
-		if err != nil {
-			// Project doesn't exist, create it
-			project := &core.Project{
-				ID:       projectID,
-				Priority: 0,
-				Metadata: make(map[string]any),
-			}
-			if err := r.store.SaveProject(project); err != nil {
-				return nil, fmt.Errorf("failed to save project: %w", err)
-			}
-		}
-	}
 
 	// If we have blocking questions, return them
 	if len(questions) > 0 {
@@ -95,7 +68,7 @@ func (r *Rules) validateCommitment(candidate core.Candidate, entry *core.Entry)
 	expRaw = candidate.Data["expectation"].(map[string]any)
 	description := expRaw["description"].(string)
 	deadlineStr := expRaw["deadline"].(string)
-	deadline, err := time.Parse("2006-01-02", deadlineStr)
+	deadline, err := time.ParseInLocation("2006-01-02", deadlineStr, r.store.Location())
 	if err != nil {
 		return nil, fmt.Errorf("invalid deadline: %w", err)
 	}
@@ -105,9 +78,16 @@ func (r *Rules) validateCommitment(candidate core.Candidate, entry *core.Entry)
 		hardness = h
 	}
 
+	id, err := r.store.NewID(store.KindCommitment)
+	if err != nil {
+		return nil, err
+	}
+
+	// Made when the entry says, which is earlier for a backdated one
+	created := entry.Timestamp
 	commitment := &core.Commitment{
-		ID:          generateID(),
-		CreatedAt:   time.Now(),
+		ID:          id,
+		CreatedAt:   created,
 		SourceEntry: entry.ID,
 		PersonID:    personID,
 		ProjectID:   projectID,
@@ -116,20 +96,23 @@ func (r *Rules) validateCommitment(candidate core.Candidate, entry *core.Entry)
 			Deadline:    deadline,
 			Hardness:    hardness,
 		},
-		Status:  core.StatusOpen,
-		History: []core.CommitmentEvent{},
+		Status: core.StatusOpen,
+		History: []core.CommitmentEvent{{
+			Timestamp:   created,
+			Type:        "created",
+			Description: description,
+			EntryID:     entry.ID,
+		}},
 	}
 
 	return &ValidationResult{
 		Valid: true,
 		Action: Action{
-			Type:       core.IntentCommitment,
-			Entry:      entry,
-			Commitment: commitment,
+			Type:        core.IntentCommitment,
+			Entry:       entry,
+			Commitment:  commitment,
+			NewPeople:   r.newPerson(personID),
+			NewProjects: r.newProject(projectID),
 		},
 	}, nil
 }
-
-func generateID() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
-}
@@ -0,0 +1,105 @@
+package rules
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+)
+
+// transitions lists the statuses each status may move to. Closed
+// commitments (fulfilled, violated, archived) only leave that state by being
+// reopened, never by an ordinary update.
+var transitions = map[core.CommitmentStatus][]core.CommitmentStatus{
+	core.StatusDraft:     {core.StatusOpen, core.StatusArchived},
+	core.StatusOpen:      {core.StatusUpdated, core.StatusFulfilled, core.StatusViolated, core.StatusArchived},
+	core.StatusUpdated:   {core.StatusUpdated, core.StatusFulfilled, core.StatusViolated, core.StatusArchived},
+	core.StatusViolated:  {core.StatusOpen, core.StatusFulfilled, core.StatusArchived},
+	core.StatusFulfilled: {core.StatusOpen, core.StatusArchived},
+	core.StatusArchived:  {core.StatusOpen},
+}
+
+// TransitionError is returned for a status change the lifecycle doesn't allow
+type TransitionError struct {
+	CommitmentID string
+	From         core.CommitmentStatus
+	To           core.CommitmentStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("commitment %s is %s and can't become %s", e.CommitmentID, e.From, e.To)
+}
+
+// CanTransition reports whether a commitment may move from one status to
+// another
+func CanTransition(from, to core.CommitmentStatus) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves commitment to status to and records the change in its
+// History along with the entry that triggered it (empty for automatic
+// changes like the violation sweep). Illegal moves leave the commitment
+// untouched and return a *TransitionError.
+func Transition(commitment *core.Commitment, to core.CommitmentStatus, at time.Time, entryID, description string) error {
+	if !CanTransition(commitment.Status, to) {
+		return &TransitionError{CommitmentID: commitment.ID, From: commitment.Status, To: to}
+	}
+
+	commitment.Status = to
+	commitment.LastUpdateAt = &at
+	commitment.History = append(commitment.History, core.CommitmentEvent{
+		Timestamp:   at,
+		Type:        string(to),
+		Description: description,
+		EntryID:     entryID,
+	})
+	return nil
+}
+
+// Renegotiate moves a commitment's deadline, keeping the deadline it
+// replaces in History and counting a slip when the new one is later. A
+// violated commitment is reopened by it; fulfilled and archived ones can't be
+// renegotiated.
+func Renegotiate(commitment *core.Commitment, deadline, at time.Time, entryID, description string) error {
+	switch commitment.Status {
+	case core.StatusFulfilled, core.StatusArchived:
+		return fmt.Errorf("commitment %s is %s, its deadline can't move", commitment.ID, commitment.Status)
+	case core.StatusViolated:
+		if err := Transition(commitment, core.StatusOpen, at, entryID, "reopened by renegotiation"); err != nil {
+			return err
+		}
+	}
+
+	previous := commitment.Expectation.Deadline
+	if deadline.After(previous) {
+		commitment.Slips++
+	}
+	commitment.Expectation.Deadline = deadline
+	commitment.LastUpdateAt = &at
+	commitment.History = append(commitment.History, core.CommitmentEvent{
+		Timestamp:   at,
+		Type:        "renegotiated",
+		Description: description,
+		EntryID:     entryID,
+		Deadline:    &previous,
+	})
+	return nil
+}
+
+// Correct records in History that a commitment was amended after the fact,
+// by a correction rather than a change in the world. The caller makes the
+// change itself; the status lifecycle doesn't apply to corrections.
+func Correct(commitment *core.Commitment, at time.Time, entryID, description string) {
+	commitment.LastUpdateAt = &at
+	commitment.History = append(commitment.History, core.CommitmentEvent{
+		Timestamp:   at,
+		Type:        "corrected",
+		Description: description,
+		EntryID:     entryID,
+	})
+}
@@ -9,11 +9,14 @@ import (
 )
 
 type Rules struct {
-	store *store.Store
+	store         store.Store
+	softGraceDays int // days past a soft deadline before it counts as violated
 }
 
-func New(s *store.Store) *Rules {
-	return &Rules{store: s}
+// New creates the rules. softGraceDays is how long a soft commitment may run
+// past its deadline before the sweep marks it violated.
+func New(s store.Store, softGraceDays int) *Rules {
+	return &Rules{store: s, softGraceDays: softGraceDays}
 }
 
 // ValidateCandidate validates a candidate and returns either a validated action or blocking questions
@@ -28,8 +31,14 @@ type Action struct {
 	Entry      *core.Entry
 	Commitment *core.Commitment
 	Update     *CommitmentUpdate
-	Event      *Event
-	Progress   *Progress
+	Renegotiation *Renegotiation
+	Event      *core.Event
+	Progress   *core.Progress
+
+	// People and projects mentioned for the first time, saved along with
+	// the action. Validation itself writes nothing.
+	NewPeople   []*core.Person
+	NewProjects []*core.Project
 }
 
 type CommitmentUpdate struct {
@@ -38,17 +47,32 @@ type CommitmentUpdate struct {
 	Description  string
 }
 
-type Event struct {
-	Time      time.Time
-	PersonID  string
-	ProjectID string
-	Title     string
+// Renegotiation moves a commitment's deadline
+type Renegotiation struct {
+	CommitmentID string
+	Deadline     time.Time
+}
+
+
+// newPerson is a placeholder for a person mentioned for the first time, nil
+// if they're known
+func (r *Rules) newPerson(id string) []*core.Person {
+	if _, err := r.store.GetPerson(id); err == nil {
+		return nil
+	}
+	return []*core.Person{{ID: id, Name: id, Metadata: make(map[string]any)}}
 }
 
-type Progress struct {
-	ProjectID string
-	Status    string
-	Notes     string
+// newProject is a placeholder for a project mentioned for the first time,
+// nil if it's known
+func (r *Rules) newProject(id string) []*core.Project {
+	if id == "" {
+		return nil
+	}
+	if _, err := r.store.GetProject(id); err == nil {
+		return nil
+	}
+	return []*core.Project{{ID: id, Priority: 0, Metadata: make(map[string]any)}}
 }
 
 func (r *Rules) Validate(candidate core.Candidate, entry *core.Entry) (*ValidationResult, error) {
@@ -57,6 +81,8 @@ func (r *Rules) Validate(candidate core.Candidate, entry *core.Entry) (*Validati
 		return r.validateCommitment(candidate, entry)
 	case core.IntentUpdate:
 		return r.validateUpdate(candidate, entry)
+	case core.IntentRenegotiation:
+		return r.validateRenegotiation(candidate, entry)
 	case core.IntentProgress:
 		return r.validateProgress(candidate, entry)
 	case core.IntentEvent:
@@ -2,12 +2,97 @@ package rules
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/store"
 )
 
 func (r *Rules) validateUpdate(candidate core.Candidate, entry *core.Entry) (*ValidationResult, error) {
+	commitment, questions, err := r.findCommitment(candidate)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(questions) > 0 {
+		return &ValidationResult{
+			Valid:     false,
+			Questions: questions,
+		}, nil
+	}
+
+	// Determine status update
+	status := core.StatusUpdated
+	description, _ := candidate.Data["status"].(string)
+	if description == "done" || description == "completed" || description == "finished" {
+		status = core.StatusFulfilled
+	}
+
+	if !CanTransition(commitment.Status, status) {
+		return nil, &TransitionError{CommitmentID: commitment.ID, From: commitment.Status, To: status}
+	}
+
+	update := &CommitmentUpdate{
+		CommitmentID: commitment.ID,
+		Status:       status,
+		Description:  description,
+	}
+
+	return &ValidationResult{
+		Valid: true,
+		Action: Action{
+			Type:   core.IntentUpdate,
+			Entry:  entry,
+			Update: update,
+		},
+	}, nil
+}
+
+func (r *Rules) validateRenegotiation(candidate core.Candidate, entry *core.Entry) (*ValidationResult, error) {
+	commitment, questions, err := r.findCommitment(candidate)
+	if err != nil {
+		return nil, err
+	}
+
+	deadlineStr, _ := candidate.Data["deadline"].(string)
+	if deadlineStr == "" {
+		questions = append(questions, core.Question{
+			ID:       "deadline",
+			Text:     "what's the new deadline?",
+			Required: true,
+			Field:    "deadline",
+		})
+	}
+
+	if len(questions) > 0 {
+		return &ValidationResult{
+			Valid:     false,
+			Questions: questions,
+		}, nil
+	}
+
+	deadline, err := time.ParseInLocation("2006-01-02", deadlineStr, r.store.Location())
+	if err != nil {
+		return nil, fmt.Errorf("invalid deadline: %w", err)
+	}
+
+	return &ValidationResult{
+		Valid: true,
+		Action: Action{
+			Type:  core.IntentRenegotiation,
+			Entry: entry,
+			Renegotiation: &Renegotiation{
+				CommitmentID: commitment.ID,
+				Deadline:     deadline,
+			},
+		},
+	}, nil
+}
+
+// findCommitment finds the open commitment a candidate refers to, by
+// commitment_id or else by person or project, asking when it can't tell
+func (r *Rules) findCommitment(candidate core.Candidate) (*core.Commitment, []core.Question, error) {
 	var questions []core.Question
 
 	// Try to find commitment by ID first
@@ -16,6 +101,9 @@ func (r *Rules) validateUpdate(candidate core.Candidate, entry *core.Entry) (*Va
 	var err error
 
 	if commitmentID != "" {
+		if id, resolveErr := r.store.ResolveID(store.KindCommitment, commitmentID); resolveErr == nil {
+			commitmentID = id
+		}
 		commitment, err = r.store.GetCommitment(commitmentID)
 		if err != nil {
 			questions = append(questions, core.Question{
@@ -47,7 +135,7 @@ func (r *Rules) validateUpdate(candidate core.Candidate, entry *core.Entry) (*Va
 			}
 
 			if err != nil {
-				return nil, fmt.Errorf("failed to find commitments: %w", err)
+				return nil, nil, fmt.Errorf("failed to find commitments: %w", err)
 			}
 
 			// Filter to open/updated commitments
@@ -70,7 +158,7 @@ func (r *Rules) validateUpdate(candidate core.Candidate, entry *core.Entry) (*Va
 			} else {
 				questions = append(questions, core.Question{
 					ID:       "commitment_ambiguous",
-					Text:     fmt.Sprintf("multiple commitments found (%d). which one?", len(open)),
+					Text:     fmt.Sprintf("multiple commitments found (%d). which one? %s", len(open), r.listCommitments(open)),
 					Required: true,
 					Field:    "commitment_id",
 				})
@@ -78,34 +166,21 @@ func (r *Rules) validateUpdate(candidate core.Candidate, entry *core.Entry) (*Va
 		}
 	}
 
-	if len(questions) > 0 {
-		return &ValidationResult{
-			Valid:     false,
-			Questions: questions,
-		}, nil
-	}
-
-	// Determine status update
-	status := core.StatusUpdated
-	description, _ := candidate.Data["status"].(string)
-	if description == "done" || description == "completed" || description == "finished" {
-		status = core.StatusFulfilled
-	}
+	return commitment, questions, nil
+}
 
-	update := &CommitmentUpdate{
-		CommitmentID: commitment.ID,
-		Status:       status,
-		Description:  description,
+// listCommitments names the commitments by short ID, for a question
+func (r *Rules) listCommitments(commitments []*core.Commitment) string {
+	aliases, _ := r.store.Aliases(store.KindCommitment)
+	var names []string
+	for _, c := range commitments {
+		id := c.ID
+		if alias, ok := aliases[id]; ok {
+			id = alias
+		}
+		names = append(names, fmt.Sprintf("%s (%s)", id, c.Expectation.Description))
 	}
-
-	return &ValidationResult{
-		Valid: true,
-		Action: Action{
-			Type:   core.IntentUpdate,
-			Entry:  entry,
-			Update: update,
-		},
-	}, nil
+	return strings.Join(names, ", ")
 }
 
 func (r *Rules) validateProgress(candidate core.Candidate, entry *core.Entry) (*ValidationResult, error) {
@@ -124,32 +199,28 @@ func (r *Rules) validateProgress(candidate core.Candidate, entry *core.Entry) (*
 		}, nil
 	}
 
-	// Check if project exists, create if not
-	_, err := r.store.GetProject(projectID)
-	if err != nil {
-		project := &core.Project{
-			ID:       projectID,
-			Priority: 0,
-			Metadata: make(map[string]any),
-		}
-		if err := r.store.SaveProject(project); err != nil {
-			return nil, fmt.Errorf("failed to save project: %w", err)
-		}
-	}
-
 	status, _ := candidate.Data["status"].(string)
 	notes, _ := candidate.Data["notes"].(string)
 
+	id, err := r.store.NewID(store.KindProgress)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ValidationResult{
 		Valid: true,
 		Action: Action{
 			Type: core.IntentProgress,
 			Entry: entry,
-			Progress: &Progress{
-				ProjectID: projectID,
-				Status:    status,
-				Notes:     notes,
+			Progress: &core.Progress{
+				ID:          id,
+				Timestamp:   entry.Timestamp,
+				SourceEntry: entry.ID,
+				ProjectID:   projectID,
+				Status:      status,
+				Notes:       notes,
 			},
+			NewProjects: r.newProject(projectID),
 		},
 	}, nil
 }
@@ -174,31 +245,120 @@ func (r *Rules) validateEvent(candidate core.Candidate, entry *core.Entry) (*Val
 		}, nil
 	}
 
-	// Parse time
-	eventTime, err := time.Parse("2006-01-02 15:04", timeStr)
+	// Parse time; a day without a time of day is an all-day event
+	allDay := false
+	start, err := time.ParseInLocation("2006-01-02 15:04", timeStr, r.store.Location())
 	if err != nil {
-		// Try other formats
-		eventTime, err = time.Parse("2006-01-02", timeStr)
+		start, err = time.ParseInLocation("2006-01-02", timeStr, r.store.Location())
 		if err != nil {
 			return nil, fmt.Errorf("invalid time format: %w", err)
 		}
+		allDay = true
+	}
+
+	end, err := r.eventEnd(candidate, start, allDay)
+	if err != nil {
+		return nil, err
 	}
 
-	personID, _ := candidate.Data["person"].(string)
 	projectID, _ := candidate.Data["project"].(string)
 	title, _ := candidate.Data["title"].(string)
+	if title == "" {
+		title = entry.Raw
+	}
+
+	id, err := r.store.NewID(store.KindCalendar)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &core.Event{
+		ID:          id,
+		CreatedAt:   r.store.Now(),
+		SourceEntry: entry.ID,
+		Title:       title,
+		Start:       start,
+		End:         end,
+		AllDay:      allDay,
+		Attendees:   attendees(candidate.Data),
+		ProjectID:   projectID,
+	}
+
+	var newPeople []*core.Person
+	for _, personID := range event.Attendees {
+		newPeople = append(newPeople, r.newPerson(personID)...)
+	}
 
 	return &ValidationResult{
 		Valid: true,
 		Action: Action{
-			Type: core.IntentEvent,
-			Entry: entry,
-			Event: &Event{
-				Time:      eventTime,
-				PersonID:  personID,
-				ProjectID: projectID,
-				Title:     title,
-			},
+			Type:        core.IntentEvent,
+			Entry:       entry,
+			Event:       event,
+			NewPeople:   newPeople,
+			NewProjects: r.newProject(projectID),
 		},
 	}, nil
 }
+
+// eventEnd works out when an event ends: at its resolved "end", or
+// "duration" minutes after it starts. An all-day event ends at midnight;
+// otherwise the end is nil when neither is given.
+func (r *Rules) eventEnd(candidate core.Candidate, start time.Time, allDay bool) (*time.Time, error) {
+	if allDay {
+		end := start.AddDate(0, 0, 1)
+		return &end, nil
+	}
+
+	if endStr, _ := candidate.Data["end"].(string); endStr != "" {
+		end, err := time.ParseInLocation("2006-01-02 15:04", endStr, r.store.Location())
+		if err != nil {
+			return nil, fmt.Errorf("invalid end time: %w", err)
+		}
+		if !end.After(start) {
+			return nil, fmt.Errorf("event ends at %s, before it starts", end.Format("2006-01-02 15:04"))
+		}
+		return &end, nil
+	}
+
+	var minutes float64
+	switch d := candidate.Data["duration"].(type) {
+	case float64:
+		minutes = d
+	case int:
+		minutes = float64(d)
+	}
+	if minutes <= 0 {
+		return nil, nil
+	}
+	end := start.Add(time.Duration(minutes * float64(time.Minute)))
+	return &end, nil
+}
+
+// attendees lists the people at an event: "attendees" and "person", without
+// repeats
+func attendees(data map[string]any) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	add := func(v any) {
+		id, _ := v.(string)
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	switch list := data["attendees"].(type) {
+	case []any:
+		for _, v := range list {
+			add(v)
+		}
+	case []string:
+		for _, v := range list {
+			add(v)
+		}
+	}
+	add(data["person"])
+	return ids
+}
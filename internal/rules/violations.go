@@ -0,0 +1,85 @@
+package rules
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+)
+
+// DefaultSoftGraceDays is how many days past its deadline a soft commitment
+// stays open before it counts as violated
+const DefaultSoftGraceDays = 2
+
+// Sweep marks open and updated commitments whose deadline has passed as
+// violated, and returns them. A hard deadline is missed as soon as its day
+// is over; a soft one gets the grace period on top. What it marks is one
+// operation undo can take back.
+func (r *Rules) Sweep(now time.Time) ([]*core.Commitment, error) {
+	var violated []*core.Commitment
+	err := r.store.Record("missed-deadline sweep", func() error {
+		open, err := r.store.ListOpenCommitments()
+		if err != nil {
+			return err
+		}
+
+		for _, commitment := range open {
+			if !r.Missed(commitment, now) {
+				continue
+			}
+
+			deadline := commitment.Expectation.Deadline
+			description := fmt.Sprintf("hard deadline %s passed", deadline.Format("2006-01-02"))
+			if commitment.Expectation.Hardness != "hard" {
+				description = fmt.Sprintf("soft deadline %s passed, %d days grace over", deadline.Format("2006-01-02"), r.softGraceDays)
+			}
+
+			if err := Transition(commitment, core.StatusViolated, now, "", description); err != nil {
+				return err
+			}
+			if err := r.store.SaveCommitment(commitment); err != nil {
+				return fmt.Errorf("failed to mark commitment violated: %w", err)
+			}
+			violated = append(violated, commitment)
+		}
+		return nil
+	})
+	return violated, err
+}
+
+// Missed reports whether commitment's deadline has passed as of now,
+// grace period included, so the sweep would mark it violated were it open
+func (r *Rules) Missed(commitment *core.Commitment, now time.Time) bool {
+	return !commitment.Expectation.Deadline.IsZero() && !now.Before(r.violatedAt(commitment))
+}
+
+// Reopen moves a closed commitment back to open, moving its deadline too
+// unless deadline is zero. The deadline it ends up with must not have been
+// missed already, or the next sweep would only mark it violated again.
+func (r *Rules) Reopen(commitment *core.Commitment, deadline, now time.Time, entryID, description string) error {
+	reopened := *commitment
+	if !deadline.IsZero() {
+		reopened.Expectation.Deadline = deadline
+	}
+	if r.Missed(&reopened, now) {
+		return fmt.Errorf("commitment %s would be due %s, which has passed; reopen it with a new deadline", commitment.ID, reopened.Expectation.Deadline.Format("2006-01-02"))
+	}
+	if err := Transition(commitment, core.StatusOpen, now, entryID, description); err != nil {
+		return err
+	}
+	if deadline.IsZero() {
+		return nil
+	}
+	return Renegotiate(commitment, deadline, now, entryID, description)
+}
+
+// violatedAt is when a commitment's deadline counts as missed: the end of
+// the deadline day, plus the grace period unless the deadline is hard
+func (r *Rules) violatedAt(commitment *core.Commitment) time.Time {
+	deadline := commitment.Expectation.Deadline.In(r.store.Location())
+	endOfDay := time.Date(deadline.Year(), deadline.Month(), deadline.Day()+1, 0, 0, 0, 0, r.store.Location())
+	if commitment.Expectation.Hardness == "hard" {
+		return endOfDay
+	}
+	return endOfDay.AddDate(0, 0, r.softGraceDays)
+}
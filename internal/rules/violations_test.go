@@ -0,0 +1,105 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+	"github.com/heywinit/grechen/internal/store"
+)
+
+func newTestRules(t *testing.T) (*Rules, store.Store) {
+	t.Helper()
+	s, err := store.NewFileStore(t.TempDir(), time.UTC)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	return New(s, DefaultSoftGraceDays), s
+}
+
+// violatedCommitment saves a hard commitment that was due on deadline and
+// swept to violated at now
+func violatedCommitment(t *testing.T, r *Rules, s store.Store, deadline, now time.Time) *core.Commitment {
+	t.Helper()
+	commitment := &core.Commitment{
+		ID:          "c-test",
+		CreatedAt:   deadline.AddDate(0, 0, -3),
+		PersonID:    "bob",
+		Expectation: core.Expectation{Description: "the report", Deadline: deadline, Hardness: "hard"},
+		Status:      core.StatusOpen,
+	}
+	if err := s.SaveCommitment(commitment); err != nil {
+		t.Fatalf("SaveCommitment: %v", err)
+	}
+	violated, err := r.Sweep(now)
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if len(violated) != 1 {
+		t.Fatalf("Sweep marked %d commitments violated, want 1", len(violated))
+	}
+	return violated[0]
+}
+
+func TestReopenThenSweep(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	r, s := newTestRules(t)
+	commitment := violatedCommitment(t, r, s, time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC), now)
+
+	// The missed deadline can't come back as it was
+	if err := r.Reopen(commitment, time.Time{}, now, "", "reopened"); err == nil {
+		t.Fatal("Reopen without a new deadline: want an error for a missed deadline")
+	}
+	if commitment.Status != core.StatusViolated {
+		t.Fatalf("status after a refused reopen = %s, want violated", commitment.Status)
+	}
+
+	// Nor can one that's just as past
+	if err := r.Reopen(commitment, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), now, "", "reopened"); err == nil {
+		t.Fatal("Reopen with a past deadline: want an error")
+	}
+
+	deadline := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+	if err := r.Reopen(commitment, deadline, now, "", "reopened"); err != nil {
+		t.Fatalf("Reopen: %v", err)
+	}
+	if err := s.SaveCommitment(commitment); err != nil {
+		t.Fatalf("SaveCommitment: %v", err)
+	}
+
+	violated, err := r.Sweep(now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if len(violated) != 0 {
+		t.Fatalf("Sweep after reopen marked %d commitments violated, want none", len(violated))
+	}
+	got, err := s.GetCommitment(commitment.ID)
+	if err != nil {
+		t.Fatalf("GetCommitment: %v", err)
+	}
+	if got.Status != core.StatusOpen || !got.Expectation.Deadline.Equal(deadline) {
+		t.Errorf("after reopen and sweep: %s due %s, want open due %s", got.Status, got.Expectation.Deadline.Format("2006-01-02"), deadline.Format("2006-01-02"))
+	}
+	if got.Slips != 1 {
+		t.Errorf("Slips = %d, want 1", got.Slips)
+	}
+}
+
+func TestReopenKeepsDeadlineStillAhead(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	r, _ := newTestRules(t)
+	deadline := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+	commitment := &core.Commitment{
+		ID:          "c-done",
+		Expectation: core.Expectation{Deadline: deadline},
+		Status:      core.StatusFulfilled,
+	}
+
+	if err := r.Reopen(commitment, time.Time{}, now, "", "reopened"); err != nil {
+		t.Fatalf("Reopen: %v", err)
+	}
+	if commitment.Status != core.StatusOpen || !commitment.Expectation.Deadline.Equal(deadline) {
+		t.Errorf("after reopen: %s due %s, want open due %s", commitment.Status, commitment.Expectation.Deadline.Format("2006-01-02"), deadline.Format("2006-01-02"))
+	}
+}
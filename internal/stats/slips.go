@@ -0,0 +1,84 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+)
+
+// SlipStats sums up how often the deadlines of one person's or one
+// project's commitments moved later
+type SlipStats struct {
+	Key         string // person or project ID
+	Commitments int    // commitments counted
+	Slipped     int    // commitments that slipped at least once
+	Slips       int    // slips across all commitments
+	DaysSlipped int    // days between original and current deadlines
+}
+
+// ComputeSlipStats groups slips by person and by project. Only groups with
+// at least one slip are returned, most slips first.
+func (s *Stats) ComputeSlipStats() (byPerson, byProject []SlipStats, err error) {
+	commitments, err := s.store.ListCommitments()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	people := map[string]*SlipStats{}
+	projects := map[string]*SlipStats{}
+	for _, c := range commitments {
+		if c.PersonID != "" {
+			addSlips(people, c.PersonID, c)
+		}
+		if c.ProjectID != "" {
+			addSlips(projects, c.ProjectID, c)
+		}
+	}
+	return sortedSlips(people), sortedSlips(projects), nil
+}
+
+func addSlips(groups map[string]*SlipStats, key string, c *core.Commitment) {
+	group, ok := groups[key]
+	if !ok {
+		group = &SlipStats{Key: key}
+		groups[key] = group
+	}
+	group.Commitments++
+	if c.Slips == 0 {
+		return
+	}
+	group.Slipped++
+	group.Slips += c.Slips
+
+	original := originalDeadline(c)
+	if !original.IsZero() && c.Expectation.Deadline.After(original) {
+		group.DaysSlipped += int(c.Expectation.Deadline.Sub(original).Hours() / 24)
+	}
+}
+
+// originalDeadline is the deadline before the first renegotiation
+func originalDeadline(c *core.Commitment) time.Time {
+	for _, event := range c.History {
+		if event.Type == "renegotiated" && event.Deadline != nil {
+			return *event.Deadline
+		}
+	}
+	return c.Expectation.Deadline
+}
+
+func sortedSlips(groups map[string]*SlipStats) []SlipStats {
+	var out []SlipStats
+	for _, group := range groups {
+		if group.Slips > 0 {
+			out = append(out, *group)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Slips != out[j].Slips {
+			return out[i].Slips > out[j].Slips
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
@@ -8,17 +8,16 @@ import (
 )
 
 type Stats struct {
-	store *store.Store
+	store store.Store
 }
 
-func New(s *store.Store) *Stats {
+func New(s store.Store) *Stats {
 	return &Stats{store: s}
 }
 
 // ComputeDailyStats computes statistics for a given day
 func (s *Stats) ComputeDailyStats(date time.Time) (*core.DailyStats, error) {
-	// Read daily file
-	content, err := s.store.ReadDailyFile(date)
+	entries, err := s.store.ListDailyEntries(date)
 	if err != nil {
 		return nil, err
 	}
@@ -27,18 +26,25 @@ func (s *Stats) ComputeDailyStats(date time.Time) (*core.DailyStats, error) {
 		Date: date,
 	}
 
-	// Parse content to extract stats
 	// Count log entries
-	stats.LogCount = countLogEntries(content)
+	stats.LogCount = countLogEntries(entries)
 
 	// Find work start time (first progress entry)
-	stats.WorkStartTime = findWorkStartTime(content, date)
+	stats.WorkStartTime = findWorkStartTime(entries)
 
-	// Count progress entries
-	stats.ProgressEntries = countProgressEntries(content)
+	// Count progress reported that day; days from before progress was
+	// stored have no records, so their progress lines are counted instead
+	progress, err := s.store.ListProgressBetween(date, date.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	stats.ProgressEntries = len(progress)
+	if len(progress) == 0 {
+		stats.ProgressEntries = countProgressEntries(entries)
+	}
 
 	// Count commitment updates
-	stats.CommitmentUpdates = countCommitmentUpdates(content)
+	stats.CommitmentUpdates = countCommitmentUpdates(entries)
 
 	return stats, nil
 }
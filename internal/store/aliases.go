@@ -0,0 +1,239 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/heywinit/grechen/internal/core"
+)
+
+// aliasesFile maps the short aliases of IDs from before short IDs existed
+// to those IDs, by kind
+const aliasesFile = "aliases.json"
+
+func (s *FileStore) loadAliases() (map[string]map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(s.MetaDir(), aliasesFile))
+	if os.IsNotExist(err) {
+		return map[string]map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := map[string]map[string]string{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &aliases); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", aliasesFile, err)
+		}
+	}
+	return aliases, nil
+}
+
+func (s *FileStore) saveAliases(aliases map[string]map[string]string) error {
+	if err := s.captureAliases(s.loadAliases); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(aliases, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.MetaDir(), aliasesFile), data, 0644)
+}
+
+func (s *FileStore) idIndex(kind string) (*idIndex, error) {
+	aliases, err := s.loadAliases()
+	if err != nil {
+		return nil, err
+	}
+	index := newIDIndex(kind, aliases[kind])
+
+	switch kind {
+	case KindCommitment, KindEvent:
+		commitments, err := s.loadCommitments()
+		if err != nil {
+			return nil, err
+		}
+		if kind == KindEvent {
+			addEventIDs(index, commitments)
+			break
+		}
+		for _, c := range commitments {
+			index.ids[c.ID] = true
+		}
+	case KindEntry:
+		err := s.eachEntry(func(entry *core.Entry) bool {
+			index.ids[entry.ID] = true
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	case KindPending:
+		items, err := s.loadInbox()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			index.ids[item.ID] = true
+		}
+	case KindCalendar:
+		events, err := s.loadEvents()
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			index.ids[event.ID] = true
+		}
+	case KindProgress:
+		records, err := s.loadProgress()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range records {
+			index.ids[p.ID] = true
+		}
+	default:
+		return nil, fmt.Errorf("unknown id kind %q", kind)
+	}
+	if err := s.retireIDs(index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// retireIDs keeps IDs still held by the undo history and the commitment
+// journal from being given out again
+func (s *FileStore) retireIDs(index *idIndex) error {
+	if err := s.retireUndoIDs(index); err != nil {
+		return err
+	}
+	if index.kind != KindCommitment && index.kind != KindEvent {
+		return nil
+	}
+	events, err := s.journalEvents()
+	if err != nil {
+		return err
+	}
+	retireJournalIDs(index, events)
+	return nil
+}
+
+// NewID returns a short ID of kind that no record uses
+func (s *FileStore) NewID(kind string) (string, error) {
+	index, err := s.idIndex(kind)
+	if err != nil {
+		return "", err
+	}
+	return index.newID()
+}
+
+// ClaimID returns id if no record of kind uses it yet, or else a new ID.
+// NewID runs before the store lock is taken, so another grechen process may
+// have used the same ID since; callers hold the lock.
+func (s *FileStore) ClaimID(kind, id string) (string, error) {
+	index, err := s.idIndex(kind)
+	if err != nil {
+		return "", err
+	}
+	if !index.taken(id) {
+		return id, nil
+	}
+	return index.newID()
+}
+
+// ResolveID returns the ID of kind that idOrPrefix names: an ID, an alias,
+// or a prefix of only one of them
+func (s *FileStore) ResolveID(kind, idOrPrefix string) (string, error) {
+	index, err := s.idIndex(kind)
+	if err != nil {
+		return "", err
+	}
+	return index.resolve(idOrPrefix)
+}
+
+// Aliases maps IDs of kind that have a short alias to that alias
+func (s *FileStore) Aliases(kind string) (map[string]string, error) {
+	aliases, err := s.loadAliases()
+	if err != nil {
+		return nil, err
+	}
+	return newIDIndex(kind, aliases[kind]).byID(), nil
+}
+
+// AssignAliases gives commitments and entries recorded before short IDs
+// existed a short alias, and their history events an ID
+func (s *FileStore) AssignAliases() (*AliasMigration, error) {
+	m := &AliasMigration{}
+	err := s.Update(func() error {
+		aliases, err := s.loadAliases()
+		if err != nil {
+			return err
+		}
+		commitments, err := s.loadCommitments()
+		if err != nil {
+			return err
+		}
+
+		index := newIDIndex(KindCommitment, aliases[KindCommitment])
+		for _, c := range commitments {
+			index.ids[c.ID] = true
+		}
+		aliased := index.byID()
+		for _, c := range commitments {
+			if isShortID(KindCommitment, c.ID) || aliased[c.ID] != "" {
+				continue
+			}
+			if err := index.alias(c.ID); err != nil {
+				return err
+			}
+			m.Commitments++
+		}
+		aliases[KindCommitment] = index.aliases
+
+		index = newIDIndex(KindEntry, aliases[KindEntry])
+		var entryIDs []string
+		err = s.eachEntry(func(entry *core.Entry) bool {
+			if !index.ids[entry.ID] {
+				index.ids[entry.ID] = true
+				entryIDs = append(entryIDs, entry.ID)
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		aliased = index.byID()
+		for _, id := range entryIDs {
+			if isShortID(KindEntry, id) || aliased[id] != "" {
+				continue
+			}
+			if err := index.alias(id); err != nil {
+				return err
+			}
+			m.Entries++
+		}
+		aliases[KindEntry] = index.aliases
+
+		if m.Commitments > 0 || m.Entries > 0 {
+			if err := s.saveAliases(aliases); err != nil {
+				return fmt.Errorf("failed to save aliases: %w", err)
+			}
+		}
+
+		// Saving fills in missing event IDs
+		for _, c := range commitments {
+			missing := countMissingEventIDs(c)
+			if missing == 0 {
+				continue
+			}
+			if err := s.saveCommitment(c); err != nil {
+				return err
+			}
+			m.Events += missing
+		}
+		return nil
+	})
+	return m, err
+}
@@ -1,8 +1,10 @@
 package store
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"time"
@@ -10,31 +12,83 @@ import (
 	"github.com/heywinit/grechen/internal/core"
 )
 
-const commitmentsFile = "commitments.json"
+const (
+	// Commitments are recorded as events appended to the journal. The
+	// snapshot holds the state they add up to as of JournalOffset in the
+	// store info, so loading only replays the events written since.
+	journalFile      = "commitments.jsonl"
+	commitmentsFile  = "commitments.json"
+	snapshotInterval = 20 // events replayed on load before the snapshot is refreshed
+)
 
-func (s *Store) SaveCommitment(commitment *core.Commitment) error {
-	commitments, err := s.loadCommitments()
+func (s *FileStore) SaveCommitment(commitment *core.Commitment) error {
+	return s.Update(func() error { return s.saveCommitment(commitment) })
+}
+
+func (s *FileStore) saveCommitment(commitment *core.Commitment) error {
+	captureRecord(&s.base, commitment.ID, s.GetCommitment)
+	commitments, tail, err := s.loadProjection()
 	if err != nil {
 		return err
 	}
 
-	// Update or add commitment
-	found := false
-	for i, c := range commitments {
+	events := newIDIndex(KindEvent, nil)
+	addEventIDs(events, commitments)
+	if err := s.retireIDs(events); err != nil {
+		return err
+	}
+	if err := assignEventIDs(commitment, events); err != nil {
+		return err
+	}
+
+	var prev *core.Commitment
+	for _, c := range commitments {
 		if c.ID == commitment.ID {
-			commitments[i] = commitment
-			found = true
+			prev = c
 			break
 		}
 	}
-	if !found {
-		commitments = append(commitments, commitment)
+	eventType, ok := journalEventType(prev, commitment)
+	if !ok {
+		return nil
 	}
 
-	return s.saveCommitments(commitments)
+	return s.recordEvent(commitments, tail, JournalEvent{At: eventAt(eventType, commitment, s.Now()), Type: eventType, Commitment: commitment})
 }
 
-func (s *Store) GetCommitment(id string) (*core.Commitment, error) {
+// DeleteCommitment removes a commitment, recording its removal in the
+// journal. Removing one that doesn't exist does nothing.
+func (s *FileStore) DeleteCommitment(id string) error {
+	captureRecord(&s.base, id, s.GetCommitment)
+	return s.Update(func() error {
+		commitments, tail, err := s.loadProjection()
+		if err != nil {
+			return err
+		}
+		for _, c := range commitments {
+			if c.ID == id {
+				return s.recordEvent(commitments, tail, JournalEvent{At: s.Now(), Type: EventDeleted, Commitment: c})
+			}
+		}
+		return nil
+	})
+}
+
+// recordEvent appends event to the journal, refreshing the snapshot of
+// commitments once enough events have been written since the last one
+func (s *FileStore) recordEvent(commitments []*core.Commitment, tail int, event JournalEvent) error {
+	offset, err := s.appendJournal(event)
+	if err != nil {
+		return err
+	}
+
+	if tail+1 < snapshotInterval {
+		return nil
+	}
+	return s.writeSnapshot(applyEvents(commitments, []JournalEvent{event}), offset)
+}
+
+func (s *FileStore) GetCommitment(id string) (*core.Commitment, error) {
 	commitments, err := s.loadCommitments()
 	if err != nil {
 		return nil, err
@@ -49,11 +103,11 @@ func (s *Store) GetCommitment(id string) (*core.Commitment, error) {
 	return nil, fmt.Errorf("commitment not found: %s", id)
 }
 
-func (s *Store) ListCommitments() ([]*core.Commitment, error) {
+func (s *FileStore) ListCommitments() ([]*core.Commitment, error) {
 	return s.loadCommitments()
 }
 
-func (s *Store) ListOpenCommitments() ([]*core.Commitment, error) {
+func (s *FileStore) ListOpenCommitments() ([]*core.Commitment, error) {
 	all, err := s.loadCommitments()
 	if err != nil {
 		return nil, err
@@ -69,7 +123,7 @@ func (s *Store) ListOpenCommitments() ([]*core.Commitment, error) {
 	return open, nil
 }
 
-func (s *Store) ListCommitmentsByPerson(personID string) ([]*core.Commitment, error) {
+func (s *FileStore) ListCommitmentsByPerson(personID string) ([]*core.Commitment, error) {
 	all, err := s.loadCommitments()
 	if err != nil {
 		return nil, err
@@ -85,7 +139,7 @@ func (s *Store) ListCommitmentsByPerson(personID string) ([]*core.Commitment, er
 	return filtered, nil
 }
 
-func (s *Store) ListCommitmentsByProject(projectID string) ([]*core.Commitment, error) {
+func (s *FileStore) ListCommitmentsByProject(projectID string) ([]*core.Commitment, error) {
 	all, err := s.loadCommitments()
 	if err != nil {
 		return nil, err
@@ -101,7 +155,7 @@ func (s *Store) ListCommitmentsByProject(projectID string) ([]*core.Commitment,
 	return filtered, nil
 }
 
-func (s *Store) ListCommitmentsDueBefore(deadline time.Time) ([]*core.Commitment, error) {
+func (s *FileStore) ListCommitmentsDueBefore(deadline time.Time) ([]*core.Commitment, error) {
 	all, err := s.loadCommitments()
 	if err != nil {
 		return nil, err
@@ -109,7 +163,7 @@ func (s *Store) ListCommitmentsDueBefore(deadline time.Time) ([]*core.Commitment
 
 	var filtered []*core.Commitment
 	for _, c := range all {
-		if c.Expectation.Deadline.Before(deadline) && (c.Status == core.StatusOpen || c.Status == core.StatusUpdated) {
+		if !c.Expectation.Deadline.IsZero() && c.Expectation.Deadline.Before(deadline) && (c.Status == core.StatusOpen || c.Status == core.StatusUpdated) {
 			filtered = append(filtered, c)
 		}
 	}
@@ -118,7 +172,7 @@ func (s *Store) ListCommitmentsDueBefore(deadline time.Time) ([]*core.Commitment
 }
 
 // ListOpenCommitmentsFromPreviousDays returns open commitments created before today
-func (s *Store) ListOpenCommitmentsFromPreviousDays(today time.Time) ([]*core.Commitment, error) {
+func (s *FileStore) ListOpenCommitmentsFromPreviousDays(today time.Time) ([]*core.Commitment, error) {
 	all, err := s.loadCommitments()
 	if err != nil {
 		return nil, err
@@ -137,7 +191,156 @@ func (s *Store) ListOpenCommitmentsFromPreviousDays(today time.Time) ([]*core.Co
 	return filtered, nil
 }
 
-func (s *Store) loadCommitments() ([]*core.Commitment, error) {
+// ListCommitmentsAt returns the commitments as they stood at t
+func (s *FileStore) ListCommitmentsAt(t time.Time) ([]*core.Commitment, error) {
+	events, err := s.journalEvents()
+	if err != nil {
+		return nil, err
+	}
+	return projection(events, t), nil
+}
+
+func (s *FileStore) loadCommitments() ([]*core.Commitment, error) {
+	commitments, _, err := s.loadProjection()
+	return commitments, err
+}
+
+// loadProjection returns the current commitments and how many journal
+// events had to be replayed on top of the snapshot to get them
+func (s *FileStore) loadProjection() ([]*core.Commitment, int, error) {
+	commitments, err := s.loadSnapshot()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := s.LoadInfo()
+	if err != nil {
+		return nil, 0, err
+	}
+	tail, _, err := s.readJournal(info.JournalOffset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return applyEvents(commitments, tail), len(tail), nil
+}
+
+// journalEvents returns every event in the journal. Data from before the
+// journal existed is described by events reconstructed from the snapshot.
+func (s *FileStore) journalEvents() ([]JournalEvent, error) {
+	if _, err := os.Stat(filepath.Join(s.MetaDir(), journalFile)); os.IsNotExist(err) {
+		commitments, err := s.loadSnapshot()
+		if err != nil {
+			return nil, err
+		}
+		var events []JournalEvent
+		for _, c := range commitments {
+			events = append(events, seedEvents(c)...)
+		}
+		return events, nil
+	}
+
+	events, _, err := s.readJournal(0)
+	return events, err
+}
+
+// readJournal reads the events written from offset on, returning them and
+// the offset just past the last complete one. A partly written last line,
+// left by a crash mid-append, is not an event.
+func (s *FileStore) readJournal(offset int64) ([]JournalEvent, int64, error) {
+	f, err := os.Open(filepath.Join(s.MetaDir(), journalFile))
+	if os.IsNotExist(err) {
+		return nil, offset, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var events []JournalEvent
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		line := data[:i]
+		data = data[i+1:]
+		offset += int64(i + 1)
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var event JournalEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal journal event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, offset, nil
+}
+
+// appendJournal appends event to the journal and returns the journal's new
+// length. The first append seeds the journal from existing data.
+func (s *FileStore) appendJournal(event JournalEvent) (int64, error) {
+	if err := s.seedJournal(); err != nil {
+		return 0, err
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal journal event: %w", err)
+	}
+
+	offset, err := appendLine(filepath.Join(s.MetaDir(), journalFile), line)
+	if err != nil {
+		return 0, fmt.Errorf("failed to append to journal: %w", err)
+	}
+	return offset, nil
+}
+
+// seedJournal starts the journal for data recorded before it existed,
+// describing each commitment in the snapshot with reconstructed events
+func (s *FileStore) seedJournal() error {
+	filename := filepath.Join(s.MetaDir(), journalFile)
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		return err
+	}
+
+	events, err := s.journalEvents()
+	if err != nil {
+		return err
+	}
+	var data []byte
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal journal event: %w", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	if err := writeFileAtomic(filename, data, 0644); err != nil {
+		return fmt.Errorf("failed to create journal: %w", err)
+	}
+
+	// The snapshot already reflects the seeded events
+	info, err := s.LoadInfo()
+	if err != nil {
+		return err
+	}
+	info.JournalOffset = int64(len(data))
+	return s.saveInfo(info)
+}
+
+func (s *FileStore) loadSnapshot() ([]*core.Commitment, error) {
 	filename := filepath.Join(s.MetaDir(), commitmentsFile)
 	data, err := os.ReadFile(filename)
 	if os.IsNotExist(err) {
@@ -159,12 +362,24 @@ func (s *Store) loadCommitments() ([]*core.Commitment, error) {
 	return commitments, nil
 }
 
-func (s *Store) saveCommitments(commitments []*core.Commitment) error {
+// writeSnapshot records commitments as the state of the journal up to offset.
+// The offset is saved last: if that's lost, the next load replays events the
+// snapshot already reflects, which changes nothing.
+func (s *FileStore) writeSnapshot(commitments []*core.Commitment, offset int64) error {
 	filename := filepath.Join(s.MetaDir(), commitmentsFile)
 	data, err := json.MarshalIndent(commitments, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal commitments: %w", err)
 	}
 
-	return os.WriteFile(filename, data, 0644)
+	if err := writeFileAtomic(filename, data, 0644); err != nil {
+		return err
+	}
+
+	info, err := s.LoadInfo()
+	if err != nil {
+		return err
+	}
+	info.JournalOffset = offset
+	return s.saveInfo(info)
 }
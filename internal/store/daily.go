@@ -4,29 +4,54 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/heywinit/grechen/internal/core"
 )
 
-func (s *Store) AppendLog(date time.Time, entry *core.Entry) error {
-	filename := s.dailyFilename(date)
-	return s.appendToSection(filename, "logs", formatLogEntry(entry))
+func (s *base) AppendLog(date time.Time, entry *core.Entry) error {
+	return s.insertIntoDay(date, SectionLogs, logItem(entry, s.loc))
 }
 
-func (s *Store) AppendCommitment(date time.Time, commitment *core.Commitment) error {
-	filename := s.dailyFilename(date)
-	line := formatCommitment(commitment)
-	return s.appendToSection(filename, "commitments", line)
+func (s *base) AppendCommitment(date time.Time, commitment *core.Commitment) error {
+	return s.insertIntoDay(date, SectionCommitments, NewItem(formatCommitment(commitment)))
 }
 
-func (s *Store) AppendNote(date time.Time, text string) error {
-	filename := s.dailyFilename(date)
-	return s.appendToSection(filename, "notes", text)
+func (s *base) AppendNote(date time.Time, text string) error {
+	return s.insertIntoDay(date, SectionNotes, NewNote(text))
 }
 
-func (s *Store) ReadDailyFile(date time.Time) (string, error) {
+// ReplaceLog rewrites the log line entry left in a day's file to read raw
+// instead, at the same time, or removes it when raw is empty. A day without
+// the line is left as it is.
+func (s *base) ReplaceLog(date time.Time, entry *core.Entry, raw string) error {
+	var replacement *Block
+	if raw != "" {
+		amended := *entry
+		amended.Raw = raw
+		replacement = logItem(&amended, s.loc)
+	}
+	return s.Update(func() error {
+		return s.replaceInDay(date, SectionLogs, logItem(entry, s.loc).Line(), replacement)
+	})
+}
+
+// ReplaceCommitment rewrites the line old left in a day's commitments
+// section to describe commitment instead, or removes it when commitment is
+// nil. A day without the line is left as it is.
+func (s *base) ReplaceCommitment(date time.Time, old, commitment *core.Commitment) error {
+	var replacement *Block
+	if commitment != nil {
+		replacement = NewItem(formatCommitment(commitment))
+	}
+	return s.Update(func() error {
+		return s.replaceInDay(date, SectionCommitments, NewItem(formatCommitment(old)).Line(), replacement)
+	})
+}
+
+func (s *base) readDailyFile(date time.Time) (string, error) {
 	filename := s.dailyFilename(date)
 	data, err := os.ReadFile(filename)
 	if os.IsNotExist(err) {
@@ -35,62 +60,106 @@ func (s *Store) ReadDailyFile(date time.Time) (string, error) {
 	return string(data), err
 }
 
-func (s *Store) dailyFilename(date time.Time) string {
-	return filepath.Join(s.DailyDir(), date.Format("2006-01-02")+".md")
+// ReadDay parses a day's file; a day without one is an empty document
+func (s *base) ReadDay(date time.Time) (*Doc, error) {
+	content, err := s.readDailyFile(date)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDoc(content, s.Day(date)), nil
 }
 
-func (s *Store) appendToSection(filename, section, content string) error {
-	// Read existing file
-	existing, err := os.ReadFile(filename)
-	fileExists := err == nil
+// WriteDay writes a document back to its day's file
+func (s *base) WriteDay(doc *Doc) error {
+	return s.Update(func() error { return s.writeDoc(doc) })
+}
 
-	var lines []string
-	if fileExists {
-		lines = strings.Split(string(existing), "\n")
+// Days returns the days that have a daily file, oldest first
+func (s *base) Days() ([]time.Time, error) {
+	files, err := filepath.Glob(filepath.Join(s.DailyDir(), "*.md"))
+	if err != nil {
+		return nil, err
 	}
+	sort.Strings(files)
 
-	// Find or create section
-	sectionHeader := fmt.Sprintf("## %s", section)
-	sectionIndex := -1
-	for i, line := range lines {
-		if strings.TrimSpace(line) == sectionHeader {
-			sectionIndex = i
-			break
+	var days []time.Time
+	for _, filename := range files {
+		day, err := time.ParseInLocation("2006-01-02", strings.TrimSuffix(filepath.Base(filename), ".md"), s.loc)
+		if err != nil {
+			continue // not a daily file
 		}
+		days = append(days, day)
 	}
+	return days, nil
+}
 
-	// If section doesn't exist, create it
-	if sectionIndex == -1 {
-		if len(lines) > 0 && lines[len(lines)-1] != "" {
-			lines = append(lines, "")
-		}
-		lines = append(lines, sectionHeader)
-		lines = append(lines, "")
-		sectionIndex = len(lines) - 1
-	}
+// DailyEntry is an item or note in one of a daily file's sections
+type DailyEntry struct {
+	Section string     // SectionLogs, SectionCommitments, SectionNotes, or a hand-written one
+	Time    *time.Time // HHMM the line starts with, nil when it has none
+	Text    string     // the line without its bullet and HHMM
+}
 
-	// Append content after section header
-	insertIndex := sectionIndex + 1
-	// Skip empty line after header if it exists
-	if insertIndex < len(lines) && strings.TrimSpace(lines[insertIndex]) == "" {
-		insertIndex++
+// ListDailyEntries returns the entries in a day's file, in file order
+func (s *base) ListDailyEntries(date time.Time) ([]DailyEntry, error) {
+	doc, err := s.ReadDay(date)
+	if err != nil {
+		return nil, err
 	}
+	return doc.Entries(), nil
+}
 
-	// Insert content
-	newLines := make([]string, 0, len(lines)+1)
-	newLines = append(newLines, lines[:insertIndex]...)
-	newLines = append(newLines, content)
-	newLines = append(newLines, lines[insertIndex:]...)
+func (s *base) dailyFilename(date time.Time) string {
+	return filepath.Join(s.DailyDir(), s.Day(date).Format("2006-01-02")+".md")
+}
+
+// insertIntoDay adds a block to a section of a day's file, creating the
+// section and the file as needed
+func (s *base) insertIntoDay(date time.Time, section string, b *Block) error {
+	return s.Update(func() error {
+		doc, err := s.ReadDay(date)
+		if err != nil {
+			return err
+		}
+		doc.EnsureSection(section).Insert(b)
+		return s.writeDoc(doc)
+	})
+}
+
+// replaceInDay replaces the first item or note in a section of a day's file
+// reading line with b, or drops it when b is nil, along with the section
+// once it's empty. A missing line or file is left alone.
+func (s *base) replaceInDay(date time.Time, section, line string, b *Block) error {
+	doc, err := s.ReadDay(date)
+	if err != nil {
+		return err
+	}
+	sec := doc.Section(section)
+	if sec == nil {
+		return nil
+	}
+	i := sec.Find(line)
+	if i == -1 {
+		return nil
+	}
+	sec.Replace(i, b)
+	if b == nil && sec.empty() {
+		doc.Remove(section)
+	}
+	return s.writeDoc(doc)
+}
 
-	// Write file
-	return os.WriteFile(filename, []byte(strings.Join(newLines, "\n")), 0644)
+// writeDoc writes a document back to its day's file
+func (s *base) writeDoc(doc *Doc) error {
+	return s.writeDaily(s.dailyFilename(doc.Day), []byte(doc.String()))
 }
 
-func formatLogEntry(entry *core.Entry) string {
-	return fmt.Sprintf("- %s %s", entry.Timestamp.Format("1504"), entry.Raw)
+// logItem is the log line an entry is written as
+func logItem(entry *core.Entry, loc *time.Location) *Block {
+	return NewTimedItem(entry.Timestamp.In(loc), entry.Raw)
 }
 
 func formatCommitment(commitment *core.Commitment) string {
 	deadline := commitment.Expectation.Deadline.Format("2006-01-02")
-	return fmt.Sprintf("- %s → %s (due %s)", commitment.PersonID, commitment.Expectation.Description, deadline)
+	return fmt.Sprintf("%s → %s (due %s)", commitment.PersonID, commitment.Expectation.Description, deadline)
 }
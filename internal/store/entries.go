@@ -0,0 +1,116 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/heywinit/grechen/internal/core"
+)
+
+// entriesFile is the entry log: every input as it was given, with what
+// extraction made of it, one JSON object per line
+const entriesFile = "entries.jsonl"
+
+// SaveEntry appends entry to the entry log
+func (s *FileStore) SaveEntry(entry *core.Entry) error {
+	captureRecord(&s.base, entry.ID, s.GetEntry)
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal entry: %w", err)
+	}
+
+	return s.Update(func() error {
+		if _, err := appendLine(filepath.Join(s.MetaDir(), entriesFile), line); err != nil {
+			return fmt.Errorf("failed to append entry: %w", err)
+		}
+		return nil
+	})
+}
+
+// deleteEntry takes entry id out of the entry log, as when undo takes back
+// the input it came from
+func (s *FileStore) deleteEntry(id string) error {
+	return s.Update(func() error {
+		filename := filepath.Join(s.MetaDir(), entriesFile)
+		data, err := os.ReadFile(filename)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var kept []byte
+		for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+			var entry core.Entry
+			if json.Unmarshal(line, &entry) == nil && entry.ID == id {
+				continue
+			}
+			kept = append(kept, line...)
+		}
+		if err := writeFileAtomic(filename, kept, 0644); err != nil {
+			return fmt.Errorf("failed to delete entry: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *FileStore) GetEntry(id string) (*core.Entry, error) {
+	var found *core.Entry
+	err := s.eachEntry(func(entry *core.Entry) bool {
+		if entry.ID == id {
+			found = entry
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("entry not found: %s", id)
+	}
+	return found, nil
+}
+
+// eachEntry calls fn with each logged entry in order until fn returns false
+func (s *FileStore) eachEntry(fn func(entry *core.Entry) bool) error {
+	f, err := os.Open(filepath.Join(s.MetaDir(), entriesFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry core.Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // partly written by a crash
+		}
+		if !fn(&entry) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// ListEntries returns the entry log, oldest first
+func (s *FileStore) ListEntries() ([]*core.Entry, error) {
+	var entries []*core.Entry
+	err := s.eachEntry(func(entry *core.Entry) bool {
+		entries = append(entries, entry)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entries: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+)
+
+// eventsFile holds the calendar: meetings, calls and appointments
+const eventsFile = "events.json"
+
+func (s *FileStore) SaveEvent(event *core.Event) error {
+	captureRecord(&s.base, event.ID, s.GetEvent)
+	return s.Update(func() error {
+		events, err := s.loadEvents()
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for i, existing := range events {
+			if existing.ID == event.ID {
+				events[i] = event
+				found = true
+				break
+			}
+		}
+		if !found {
+			events = append(events, event)
+		}
+		return s.saveEvents(events)
+	})
+}
+
+// DeleteEvent removes an event. Removing one that doesn't exist does
+// nothing.
+func (s *FileStore) DeleteEvent(id string) error {
+	captureRecord(&s.base, id, s.GetEvent)
+	return s.Update(func() error {
+		events, err := s.loadEvents()
+		if err != nil {
+			return err
+		}
+
+		kept := events[:0]
+		for _, event := range events {
+			if event.ID != id {
+				kept = append(kept, event)
+			}
+		}
+		return s.saveEvents(kept)
+	})
+}
+
+func (s *FileStore) GetEvent(id string) (*core.Event, error) {
+	events, err := s.loadEvents()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		if event.ID == id {
+			return event, nil
+		}
+	}
+	return nil, fmt.Errorf("event not found: %s", id)
+}
+
+// ListEvents returns every event, by start time
+func (s *FileStore) ListEvents() ([]*core.Event, error) {
+	events, err := s.loadEvents()
+	if err != nil {
+		return nil, err
+	}
+	sortEvents(events)
+	return events, nil
+}
+
+// ListEventsBetween returns the events that start in [from, to) or are
+// still going on at from, by start time
+func (s *FileStore) ListEventsBetween(from, to time.Time) ([]*core.Event, error) {
+	events, err := s.ListEvents()
+	if err != nil {
+		return nil, err
+	}
+
+	var between []*core.Event
+	for _, event := range events {
+		if overlaps(event, from, to) {
+			between = append(between, event)
+		}
+	}
+	return between, nil
+}
+
+// overlaps reports whether event starts in [from, to) or is still going on
+// at from
+func overlaps(event *core.Event, from, to time.Time) bool {
+	if !event.Start.Before(to) {
+		return false
+	}
+	return !event.Start.Before(from) || (event.End != nil && event.End.After(from))
+}
+
+func sortEvents(events []*core.Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+}
+
+func (s *FileStore) loadEvents() ([]*core.Event, error) {
+	data, err := os.ReadFile(filepath.Join(s.MetaDir(), eventsFile))
+	if os.IsNotExist(err) {
+		return []*core.Event{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	events := []*core.Event{}
+	if len(data) == 0 {
+		return events, nil
+	}
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal events: %w", err)
+	}
+	return events, nil
+}
+
+func (s *FileStore) saveEvents(events []*core.Event) error {
+	data, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal events: %w", err)
+	}
+	return writeFileAtomic(filepath.Join(s.MetaDir(), eventsFile), data, 0644)
+}
@@ -0,0 +1,282 @@
+package store
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/heywinit/grechen/internal/core"
+)
+
+// Kinds of record with short IDs. The kind is the ID's prefix, so c-7fq2 is
+// a commitment.
+const (
+	KindCommitment = "c"
+	KindEntry      = "e"
+	KindEvent      = "ev"
+	KindPending    = "q"
+
+	// KindCalendar is for calendar events; KindEvent is taken by the
+	// events in a commitment's history
+	KindCalendar = "cal"
+	KindProgress = "p"
+)
+
+const (
+	idAlphabet  = "0123456789abcdefghjkmnpqrstvwxyz" // no i, l, o or u to misread
+	idLength    = 4                                  // characters after the prefix
+	idMaxLength = 12
+	idAttempts  = 16 // random picks at one length before trying a longer one
+)
+
+var kindNames = map[string]string{
+	KindCommitment: "commitment",
+	KindEntry:      "entry",
+	KindEvent:      "event",
+	KindPending:    "inbox item",
+	KindCalendar:   "calendar event",
+	KindProgress:   "progress record",
+}
+
+// IDError is returned when an ID or prefix matches no record, or more than
+// one
+type IDError struct {
+	Kind    string
+	Input   string
+	Matches []string // short forms of the IDs matched, when ambiguous
+}
+
+func (e *IDError) Error() string {
+	if len(e.Matches) == 0 {
+		return fmt.Sprintf("no %s with id %s", kindNames[e.Kind], e.Input)
+	}
+	return fmt.Sprintf("%s matches %d %s ids: %s", e.Input, len(e.Matches), kindNames[e.Kind], strings.Join(e.Matches, ", "))
+}
+
+// AliasMigration counts the short IDs given to records from before they
+// existed
+type AliasMigration struct {
+	Commitments int
+	Entries     int
+	Events      int
+}
+
+// idIndex is every ID of one kind in use, and the short aliases of IDs
+// recorded before short IDs existed
+type idIndex struct {
+	kind    string
+	ids     map[string]bool
+	aliases map[string]string // alias -> ID
+	retired map[string]bool   // no longer in use but not to be given out again
+}
+
+func newIDIndex(kind string, aliases map[string]string) *idIndex {
+	if aliases == nil {
+		aliases = map[string]string{}
+	}
+	return &idIndex{kind: kind, ids: map[string]bool{}, aliases: aliases, retired: map[string]bool{}}
+}
+
+func (x *idIndex) taken(id string) bool {
+	_, aliased := x.aliases[id]
+	return x.ids[id] || aliased || x.retired[id]
+}
+
+// newID picks an unused random ID, without recording it. IDs start at
+// idLength characters and only grow when picks keep colliding, so they stay
+// short for as long as there's room.
+func (x *idIndex) newID() (string, error) {
+	for n := idLength; n <= idMaxLength; n++ {
+		for i := 0; i < idAttempts; i++ {
+			id, err := randomID(x.kind, n)
+			if err != nil {
+				return "", err
+			}
+			if !x.taken(id) {
+				return id, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("failed to find a free %s id", kindNames[x.kind])
+}
+
+func randomID(kind string, n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate id: %w", err)
+	}
+	for i, b := range buf {
+		buf[i] = idAlphabet[int(b)%len(idAlphabet)] // 256 is a multiple of 32, so no bias
+	}
+	return kind + "-" + string(buf), nil
+}
+
+// resolve finds the ID that idOrPrefix names: an ID or alias, or a prefix
+// of exactly one of them. The kind prefix may be left out, so "7fq" finds
+// c-7fq2.
+func (x *idIndex) resolve(idOrPrefix string) (string, error) {
+	if x.ids[idOrPrefix] {
+		return idOrPrefix, nil
+	}
+	if id, ok := x.aliases[idOrPrefix]; ok {
+		return id, nil
+	}
+
+	matches := map[string]string{} // ID -> the form it matched by
+	match := func(key, id string) {
+		if idOrPrefix != "" && (strings.HasPrefix(key, idOrPrefix) || strings.HasPrefix(key, x.kind+"-"+idOrPrefix)) {
+			matches[id] = key
+		}
+	}
+	for id := range x.ids {
+		match(id, id)
+	}
+	for alias, id := range x.aliases {
+		match(alias, id)
+	}
+
+	if len(matches) == 1 {
+		for id := range matches {
+			return id, nil
+		}
+	}
+	err := &IDError{Kind: x.kind, Input: idOrPrefix}
+	aliased := x.byID()
+	for id, key := range matches {
+		if alias, ok := aliased[id]; ok {
+			key = alias
+		}
+		err.Matches = append(err.Matches, key)
+	}
+	sort.Strings(err.Matches)
+	return "", err
+}
+
+// alias gives id, from before short IDs existed, a short alias
+func (x *idIndex) alias(id string) error {
+	alias, err := x.newID()
+	if err != nil {
+		return err
+	}
+	x.aliases[alias] = id
+	return nil
+}
+
+// byID maps each aliased ID to its alias
+func (x *idIndex) byID() map[string]string {
+	out := make(map[string]string, len(x.aliases))
+	for alias, id := range x.aliases {
+		out[id] = alias
+	}
+	return out
+}
+
+// retireUndoIDs keeps the IDs in the undo history from being given out
+// again: a record deleted or undone keeps its ID there, and a new record
+// that took it would be overwritten when the operation is redone or undone
+func (s *base) retireUndoIDs(index *idIndex) error {
+	ops, err := s.loadOps()
+	if err != nil {
+		return err
+	}
+	for _, op := range ops {
+		switch index.kind {
+		case KindCommitment:
+			retireChangeIDs(index, op.Commitments)
+		case KindEvent:
+			for _, change := range op.Commitments {
+				for _, c := range []*core.Commitment{change.Before, change.After} {
+					if c == nil {
+						continue
+					}
+					for _, event := range c.History {
+						index.retired[event.ID] = true
+					}
+				}
+			}
+		case KindEntry:
+			retireChangeIDs(index, op.Entries)
+		case KindPending:
+			retireChangeIDs(index, op.Pending)
+		case KindCalendar:
+			retireChangeIDs(index, op.Events)
+		case KindProgress:
+			retireChangeIDs(index, op.Progress)
+		}
+		if op.Aliases != nil {
+			for alias := range op.Aliases.Before[index.kind] {
+				index.retired[alias] = true
+			}
+			for alias := range op.Aliases.After[index.kind] {
+				index.retired[alias] = true
+			}
+		}
+	}
+	return nil
+}
+
+// retireJournalIDs keeps the IDs of commitments in the journal, and of
+// their history events, from being given out again, so a new commitment
+// doesn't take over the history of a deleted one
+func retireJournalIDs(index *idIndex, events []JournalEvent) {
+	for _, event := range events {
+		switch index.kind {
+		case KindCommitment:
+			index.retired[event.Commitment.ID] = true
+		case KindEvent:
+			for _, e := range event.Commitment.History {
+				index.retired[e.ID] = true
+			}
+		}
+	}
+}
+
+func retireChangeIDs[T any](index *idIndex, changes []Change[T]) {
+	for _, change := range changes {
+		index.retired[change.ID] = true
+	}
+}
+
+// isShortID reports whether id is already a short ID of kind
+func isShortID(kind, id string) bool {
+	return strings.HasPrefix(id, kind+"-")
+}
+
+// assignEventIDs gives each of the commitment's history events that has no
+// ID yet a fresh one
+func assignEventIDs(commitment *core.Commitment, events *idIndex) error {
+	for i := range commitment.History {
+		if commitment.History[i].ID != "" {
+			continue
+		}
+		id, err := events.newID()
+		if err != nil {
+			return err
+		}
+		commitment.History[i].ID = id
+		events.ids[id] = true
+	}
+	return nil
+}
+
+// addEventIDs adds the IDs of the commitments' history events to events
+func addEventIDs(events *idIndex, commitments []*core.Commitment) {
+	for _, c := range commitments {
+		for _, event := range c.History {
+			if event.ID != "" {
+				events.ids[event.ID] = true
+			}
+		}
+	}
+}
+
+func countMissingEventIDs(c *core.Commitment) int {
+	n := 0
+	for _, event := range c.History {
+		if event.ID == "" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/heywinit/grechen/internal/core"
+)
+
+// inboxFile holds the candidates waiting for answers to their questions
+const inboxFile = "inbox.json"
+
+func (s *FileStore) SavePending(item *core.PendingItem) error {
+	captureRecord(&s.base, item.ID, s.GetPending)
+	return s.Update(func() error {
+		items, err := s.loadInbox()
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for i, existing := range items {
+			if existing.ID == item.ID {
+				items[i] = item
+				found = true
+				break
+			}
+		}
+		if !found {
+			items = append(items, item)
+		}
+		return s.saveInbox(items)
+	})
+}
+
+func (s *FileStore) GetPending(id string) (*core.PendingItem, error) {
+	items, err := s.loadInbox()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if item.ID == id {
+			return item, nil
+		}
+	}
+	return nil, fmt.Errorf("inbox item not found: %s", id)
+}
+
+func (s *FileStore) ListPending() ([]*core.PendingItem, error) {
+	return s.loadInbox()
+}
+
+func (s *FileStore) DeletePending(id string) error {
+	captureRecord(&s.base, id, s.GetPending)
+	return s.Update(func() error {
+		items, err := s.loadInbox()
+		if err != nil {
+			return err
+		}
+
+		kept := items[:0]
+		for _, item := range items {
+			if item.ID != id {
+				kept = append(kept, item)
+			}
+		}
+		if len(kept) == len(items) {
+			return fmt.Errorf("inbox item not found: %s", id)
+		}
+		return s.saveInbox(kept)
+	})
+}
+
+func (s *FileStore) loadInbox() ([]*core.PendingItem, error) {
+	data, err := os.ReadFile(filepath.Join(s.MetaDir(), inboxFile))
+	if os.IsNotExist(err) {
+		return []*core.PendingItem{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items := []*core.PendingItem{}
+	if len(data) == 0 {
+		return items, nil
+	}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal inbox: %w", err)
+	}
+	return items, nil
+}
+
+func (s *FileStore) saveInbox(items []*core.PendingItem) error {
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal inbox: %w", err)
+	}
+	return writeFileAtomic(filepath.Join(s.MetaDir(), inboxFile), data, 0644)
+}
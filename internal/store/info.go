@@ -0,0 +1,75 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const infoFile = "store.json"
+
+// Info records facts about how the data on disk was written, so later
+// versions can tell when existing data needs migrating
+type Info struct {
+	Timezone      string // IANA zone the daily files and deadlines are in, empty for legacy data
+	JournalOffset int64  // bytes of the commitment journal reflected in the snapshot
+}
+
+func (s *base) LoadInfo() (*Info, error) {
+	filename := filepath.Join(s.MetaDir(), infoFile)
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return &Info{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var info Info
+	if len(data) == 0 {
+		return &info, nil
+	}
+
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal store info: %w", err)
+	}
+
+	return &info, nil
+}
+
+func (s *base) SaveInfo(info *Info) error {
+	return s.Update(func() error { return s.saveInfo(info) })
+}
+
+func (s *base) saveInfo(info *Info) error {
+	filename := filepath.Join(s.MetaDir(), infoFile)
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal store info: %w", err)
+	}
+
+	return writeFileAtomic(filename, data, 0644)
+}
+
+// hasDailyFiles reports whether any daily file has been written
+func (s *base) hasDailyFiles() (bool, error) {
+	daily, err := os.ReadDir(s.DailyDir())
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	return len(daily) > 0, nil
+}
+
+// HasData reports whether anything has been recorded yet
+func (s *FileStore) HasData() (bool, error) {
+	if ok, err := s.hasDailyFiles(); ok || err != nil {
+		return ok, err
+	}
+
+	commitments, err := s.loadCommitments()
+	if err != nil {
+		return false, err
+	}
+	return len(commitments) > 0, nil
+}
@@ -0,0 +1,162 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+)
+
+// Commitment journal event types
+const (
+	EventCreated         = "created"
+	EventUpdated         = "updated"
+	EventDeadlineChanged = "deadline-changed"
+	EventFulfilled       = "fulfilled"
+	EventViolated        = "violated"
+	EventArchived        = "archived"
+
+	// EventDeleted removes a commitment, as when undo takes back the
+	// operation that created it. Its Commitment is the last state.
+	EventDeleted = "deleted"
+)
+
+// JournalEvent is one immutable record in the commitment journal. Each event
+// carries the commitment as it stood after the change, so replaying the
+// journal up to any moment gives the state at that moment.
+type JournalEvent struct {
+	At         time.Time
+	Type       string
+	Commitment *core.Commitment
+}
+
+// eventAt is when the event of eventType for commitment happened. A new
+// commitment is created at its CreatedAt, which is earlier than now when it
+// was made before it was recorded.
+func eventAt(eventType string, commitment *core.Commitment, now time.Time) time.Time {
+	if eventType == EventCreated && !commitment.CreatedAt.IsZero() && commitment.CreatedAt.Before(now) {
+		return commitment.CreatedAt
+	}
+	return now
+}
+
+// journalEventType names the change from prev to next, with prev nil for a
+// new commitment. ok is false when nothing changed.
+func journalEventType(prev, next *core.Commitment) (eventType string, ok bool) {
+	if prev == nil {
+		return EventCreated, true
+	}
+	if sameCommitment(prev, next) {
+		return "", false
+	}
+
+	if prev.Status != next.Status {
+		switch next.Status {
+		case core.StatusFulfilled:
+			return EventFulfilled, true
+		case core.StatusViolated:
+			return EventViolated, true
+		case core.StatusArchived:
+			return EventArchived, true
+		}
+	}
+	if !prev.Expectation.Deadline.Equal(next.Expectation.Deadline) {
+		return EventDeadlineChanged, true
+	}
+	return EventUpdated, true
+}
+
+func sameCommitment(a, b *core.Commitment) bool {
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(dataA) == string(dataB)
+}
+
+// projection replays events into the commitments they describe, in the
+// order the commitments were created. Events after until are skipped; a zero
+// until replays everything.
+func projection(events []JournalEvent, until time.Time) []*core.Commitment {
+	var replay []JournalEvent
+	for _, event := range ordered(events) {
+		if until.IsZero() || !event.At.After(until) {
+			replay = append(replay, event)
+		}
+	}
+	return applyEvents([]*core.Commitment{}, replay)
+}
+
+// ordered sorts events by when they happened rather than when they were
+// appended, since a backdated commitment is created at its CreatedAt. An
+// event never goes before an earlier one for the same commitment: a
+// commitment created again by redo after undo deleted it is created when
+// it's appended.
+func ordered(events []JournalEvent) []JournalEvent {
+	sorted := make([]JournalEvent, len(events))
+	last := make(map[string]time.Time)
+	for i, event := range events {
+		if prev, ok := last[event.Commitment.ID]; ok && event.At.Before(prev) {
+			event.At = prev
+		}
+		last[event.Commitment.ID] = event.At
+		sorted[i] = event
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].At.Before(sorted[j].At) })
+	return sorted
+}
+
+// applyEvents replays events on top of commitments. Replaying an event that
+// is already reflected changes nothing, since events carry whole states.
+func applyEvents(commitments []*core.Commitment, events []JournalEvent) []*core.Commitment {
+	index := make(map[string]int, len(commitments))
+	for i, c := range commitments {
+		index[c.ID] = i
+	}
+	for _, event := range events {
+		c := event.Commitment
+		if event.Type == EventDeleted {
+			if i, ok := index[c.ID]; ok {
+				commitments = append(commitments[:i], commitments[i+1:]...)
+				delete(index, c.ID)
+				for j := i; j < len(commitments); j++ {
+					index[commitments[j].ID] = j
+				}
+			}
+			continue
+		}
+		if i, ok := index[c.ID]; ok {
+			commitments[i] = c
+			continue
+		}
+		index[c.ID] = len(commitments)
+		commitments = append(commitments, c)
+	}
+	return commitments
+}
+
+// seedEvents reconstructs journal events for a commitment recorded before
+// the journal existed: its creation, then its current state as of its last
+// update
+func seedEvents(c *core.Commitment) []JournalEvent {
+	created := *c
+	created.Status = core.StatusOpen
+	created.LastUpdateAt = nil
+	created.History = nil
+	for _, event := range c.History {
+		if event.Timestamp.After(c.CreatedAt) {
+			break
+		}
+		created.History = append(created.History, event)
+	}
+
+	events := []JournalEvent{{At: c.CreatedAt, Type: EventCreated, Commitment: &created}}
+
+	at := c.CreatedAt
+	if c.LastUpdateAt != nil {
+		at = *c.LastUpdateAt
+	}
+	if eventType, ok := journalEventType(&created, c); ok {
+		events = append(events, JournalEvent{At: at, Type: eventType, Commitment: c})
+	}
+	return events
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const lockFilename = ".lock"
+
+// Update runs fn while holding an advisory lock on the data directory, so a
+// read-modify-write spanning several store calls can't interleave with
+// another grechen process. Calls nest: store mutations made inside fn reuse
+// the lock that's already held.
+//
+// The lock serialises processes, not goroutines; a Store is meant to be used
+// from a single goroutine.
+func (s *base) Update(fn func() error) error {
+	if s.lockDepth > 0 {
+		s.lockDepth++
+		defer func() { s.lockDepth-- }()
+		return fn()
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dataDir, lockFilename), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file: %w", err)
+	}
+	defer f.Close()
+
+	if err := lockFile(f); err != nil {
+		return fmt.Errorf("failed to lock data directory: %w", err)
+	}
+	defer unlockFile(f)
+
+	s.lockDepth++
+	defer func() { s.lockDepth-- }()
+	return fn()
+}
+
+// writeFileAtomic replaces filename with data so readers and crashes only
+// ever see the old or the new contents: write a temp file in the same
+// directory, fsync it, then rename it over the original
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(filename)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, filename); err != nil {
+		return err
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// appendLine appends line and a newline to filename and syncs it, returning
+// the file's new length. A partly written last line left by a crash is
+// dropped first so the new line starts on its own.
+func appendLine(filename string, line []byte) (int64, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	end := stat.Size()
+
+	last := make([]byte, 1)
+	if end > 0 {
+		if _, err := f.ReadAt(last, end-1); err != nil {
+			return 0, err
+		}
+	}
+	if end > 0 && last[0] != '\n' {
+		data := make([]byte, end)
+		if _, err := f.ReadAt(data, 0); err != nil {
+			return 0, err
+		}
+		end = int64(bytes.LastIndexByte(data, '\n') + 1)
+		if err := f.Truncate(end); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := f.WriteAt(append(line, '\n'), end); err != nil {
+		return 0, err
+	}
+	if err := f.Sync(); err != nil {
+		return 0, err
+	}
+	return end + int64(len(line)) + 1, nil
+}
+
+// syncDir flushes a rename to disk. Best effort: not every platform can
+// open a directory for syncing.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	d.Close()
+}
@@ -0,0 +1,374 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sections grechen writes to in a daily file
+const (
+	SectionLogs        = "logs"
+	SectionCommitments = "commitments"
+	SectionNotes       = "notes"
+)
+
+// Doc is a daily file parsed into its sections. It keeps every line as
+// written, so String gives back the file it was parsed from byte for byte;
+// only what's changed through its methods is written differently.
+type Doc struct {
+	Day      time.Time  // midnight of the file's day, which item times are on
+	Preamble []*Block   // whatever comes before the first section
+	Sections []*Section // "## name" sections, in file order
+}
+
+// Section is a "## name" heading and the blocks under it, up to the next
+// one
+type Section struct {
+	Name    string // the heading's name, lowercased
+	Heading string // the heading line as written
+	Blocks  []*Block
+}
+
+// BlockKind says what a block of lines is
+type BlockKind int
+
+const (
+	BlockBlank   BlockKind = iota // an empty line
+	BlockItem                     // a "- " or "* " bullet
+	BlockNote                     // a line of plain text
+	BlockUnknown                  // anything else, kept as written: other headings, fenced code
+)
+
+// Block is one or more lines of a daily file. An item or note takes the
+// indented lines that follow it along.
+type Block struct {
+	Kind  BlockKind
+	Lines []string   // as written
+	Time  *time.Time // the HHMM an item or note starts with, on the file's day
+	Text  string     // an item's or note's first line without bullet and HHMM
+}
+
+// ParseDoc parses a daily file's content. Anything it doesn't recognise is
+// kept as an unknown block, so hand-edited files read fine and are written
+// back unchanged.
+func ParseDoc(content string, day time.Time) *Doc {
+	doc := &Doc{Day: day}
+	blocks := &doc.Preamble
+
+	lines := strings.Split(content, "\n")
+	if content == "" {
+		lines = nil
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "## "):
+			section := &Section{
+				Name:    strings.ToLower(strings.TrimSpace(trimmed[3:])),
+				Heading: line,
+			}
+			doc.Sections = append(doc.Sections, section)
+			blocks = &section.Blocks
+
+		case trimmed == "":
+			*blocks = append(*blocks, &Block{Kind: BlockBlank, Lines: []string{line}})
+
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			// A fenced block runs to its closing fence, or the end of the file
+			fence := trimmed[:3]
+			block := &Block{Kind: BlockUnknown, Lines: []string{line}}
+			for i+1 < len(lines) {
+				i++
+				block.Lines = append(block.Lines, lines[i])
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+					break
+				}
+			}
+			*blocks = append(*blocks, block)
+
+		case isContinuation(line) && len(*blocks) > 0 && hasText((*blocks)[len(*blocks)-1]):
+			last := (*blocks)[len(*blocks)-1]
+			last.Lines = append(last.Lines, line)
+
+		case strings.HasPrefix(trimmed, "#"):
+			*blocks = append(*blocks, &Block{Kind: BlockUnknown, Lines: []string{line}})
+
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") || trimmed == "-" || trimmed == "*":
+			block := &Block{Kind: BlockItem, Lines: []string{line}}
+			block.setText(strings.TrimSpace(trimmed[1:]), day)
+			*blocks = append(*blocks, block)
+
+		default:
+			block := &Block{Kind: BlockNote, Lines: []string{line}}
+			block.setText(trimmed, day)
+			*blocks = append(*blocks, block)
+		}
+	}
+	return doc
+}
+
+// isContinuation reports whether a line is indented, carrying on the item
+// or note above it
+func isContinuation(line string) bool {
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+}
+
+func hasText(b *Block) bool {
+	return b.Kind == BlockItem || b.Kind == BlockNote
+}
+
+// setText sets an item's or note's text, reading the HHMM it starts with
+// as a time on day
+func (b *Block) setText(text string, day time.Time) {
+	b.Text = text
+	if t, ok := parseHHMM(text, day); ok {
+		b.Time = &t
+		b.Text = strings.TrimSpace(text[4:])
+	}
+}
+
+// parseHHMM reads the HHMM a line starts with as a time on day
+func parseHHMM(text string, day time.Time) (time.Time, bool) {
+	if len(text) < 4 || (len(text) > 4 && text[4] != ' ') {
+		return time.Time{}, false
+	}
+	hour, errH := strconv.Atoi(text[:2])
+	minute, errM := strconv.Atoi(text[2:4])
+	if errH != nil || errM != nil || hour > 23 || minute > 59 {
+		return time.Time{}, false
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location()), true
+}
+
+// NewItem is a "- text" bullet
+func NewItem(text string) *Block {
+	return &Block{Kind: BlockItem, Lines: []string{"- " + text}, Text: text}
+}
+
+// NewTimedItem is a "- HHMM text" bullet, with t's HHMM in t's location
+func NewTimedItem(t time.Time, text string) *Block {
+	return &Block{
+		Kind:  BlockItem,
+		Lines: []string{fmt.Sprintf("- %s %s", t.Format("1504"), text)},
+		Time:  &t,
+		Text:  text,
+	}
+}
+
+// NewNote is plain text; every line after the first is indented so it
+// stays part of the note
+func NewNote(text string) *Block {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i := 1; i < len(lines); i++ {
+		lines[i] = "  " + lines[i]
+	}
+	return &Block{Kind: BlockNote, Lines: lines, Text: lines[0]}
+}
+
+// Line is the block's first line as written, without surrounding space
+func (b *Block) Line() string {
+	return strings.TrimSpace(b.Lines[0])
+}
+
+// String writes the document back out
+func (d *Doc) String() string {
+	var lines []string
+	add := func(blocks []*Block) {
+		for _, b := range blocks {
+			lines = append(lines, b.Lines...)
+		}
+	}
+	add(d.Preamble)
+	for _, section := range d.Sections {
+		lines = append(lines, section.Heading)
+		add(section.Blocks)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Section returns the first section called name, or nil
+func (d *Doc) Section(name string) *Section {
+	for _, section := range d.Sections {
+		if section.Name == name {
+			return section
+		}
+	}
+	return nil
+}
+
+// EnsureSection returns the section called name, adding it at the end of
+// the document, after a blank line, when there's none
+func (d *Doc) EnsureSection(name string) *Section {
+	if section := d.Section(name); section != nil {
+		return section
+	}
+
+	last := &d.Preamble
+	if len(d.Sections) > 0 {
+		last = &d.Sections[len(d.Sections)-1].Blocks
+	}
+	if n := len(*last); n > 0 && (*last)[n-1].Kind != BlockBlank {
+		*last = append(*last, blankBlock())
+	}
+
+	section := &Section{
+		Name:    name,
+		Heading: "## " + name,
+		Blocks:  []*Block{blankBlock()},
+	}
+	d.Sections = append(d.Sections, section)
+	return section
+}
+
+// Remove drops the section called name. When it was the last section, the
+// blank lines left at the end of the document go too.
+func (d *Doc) Remove(name string) {
+	for i, section := range d.Sections {
+		if section.Name != name {
+			continue
+		}
+		d.Sections = append(d.Sections[:i], d.Sections[i+1:]...)
+		if i == len(d.Sections) {
+			last := &d.Preamble
+			if i > 0 {
+				last = &d.Sections[i-1].Blocks
+			}
+			for n := len(*last); n > 0 && (*last)[n-1].Kind == BlockBlank; n-- {
+				*last = (*last)[:n-1]
+			}
+		}
+		return
+	}
+}
+
+// empty reports whether the section holds nothing but blank lines
+func (s *Section) empty() bool {
+	for _, b := range s.Blocks {
+		if b.Kind != BlockBlank {
+			return false
+		}
+	}
+	return true
+}
+
+// Entries flattens the document into its items and notes, in file order.
+// Unknown blocks and text before the first section aren't entries.
+func (d *Doc) Entries() []DailyEntry {
+	var entries []DailyEntry
+	for _, section := range d.Sections {
+		for _, b := range section.Blocks {
+			if hasText(b) {
+				entries = append(entries, DailyEntry{Section: section.Name, Time: b.Time, Text: b.Text})
+			}
+		}
+	}
+	return entries
+}
+
+// Insert adds a block in time order: a timed item or note goes after the
+// entries up to its time and before the first later one, anything else
+// after the last entry
+func (s *Section) Insert(b *Block) {
+	i := 0
+	if len(s.Blocks) > 0 && s.Blocks[0].Kind == BlockBlank {
+		i = 1
+	}
+	for j, other := range s.Blocks {
+		if !hasText(other) {
+			continue
+		}
+		if b.Time != nil && other.Time != nil && other.Time.After(*b.Time) {
+			break
+		}
+		i = j + 1
+	}
+
+	s.Blocks = append(s.Blocks, nil)
+	copy(s.Blocks[i+1:], s.Blocks[i:])
+	s.Blocks[i] = b
+}
+
+// Find returns the index of the first item or note whose first line reads
+// line, or -1
+func (s *Section) Find(line string) int {
+	for i, b := range s.Blocks {
+		if hasText(b) && b.Line() == line {
+			return i
+		}
+	}
+	return -1
+}
+
+// Replace puts b in place of the block at i, or removes that block when b
+// is nil
+func (s *Section) Replace(i int, b *Block) {
+	if b == nil {
+		s.Blocks = append(s.Blocks[:i], s.Blocks[i+1:]...)
+		return
+	}
+	s.Blocks[i] = b
+}
+
+// Format lays the document out the way grechen writes it: each section's
+// timed entries in time order, one blank line under every heading and
+// before the next, and no blank lines at the top or in a row. Untimed
+// entries and anything grechen doesn't recognise stay where they are.
+func (d *Doc) Format() {
+	d.Preamble = collapseBlanks(d.Preamble)
+	if len(d.Preamble) > 0 && d.Preamble[0].Kind == BlockBlank {
+		d.Preamble = d.Preamble[1:]
+	}
+	for i, section := range d.Sections {
+		section.sortEntries()
+
+		blocks := collapseBlanks(section.Blocks)
+		if len(blocks) == 0 || blocks[0].Kind != BlockBlank {
+			blocks = append([]*Block{blankBlock()}, blocks...)
+		}
+		if i < len(d.Sections)-1 && blocks[len(blocks)-1].Kind != BlockBlank {
+			blocks = append(blocks, blankBlock())
+		}
+		section.Blocks = blocks
+	}
+	if n := len(d.Preamble); n > 0 && len(d.Sections) > 0 && d.Preamble[n-1].Kind != BlockBlank {
+		d.Preamble = append(d.Preamble, blankBlock())
+	}
+}
+
+// sortEntries puts the section's timed entries in time order, in the
+// places timed entries already take
+func (s *Section) sortEntries() {
+	var places []int
+	var timed []*Block
+	for i, b := range s.Blocks {
+		if hasText(b) && b.Time != nil {
+			places = append(places, i)
+			timed = append(timed, b)
+		}
+	}
+	sort.SliceStable(timed, func(i, j int) bool { return timed[i].Time.Before(*timed[j].Time) })
+	for i, place := range places {
+		s.Blocks[place] = timed[i]
+	}
+}
+
+// collapseBlanks drops every blank line that follows another
+func collapseBlanks(blocks []*Block) []*Block {
+	var kept []*Block
+	for _, b := range blocks {
+		if b.Kind == BlockBlank && len(kept) > 0 && kept[len(kept)-1].Kind == BlockBlank {
+			continue
+		}
+		kept = append(kept, b)
+	}
+	return kept
+}
+
+func blankBlock() *Block {
+	return &Block{Kind: BlockBlank, Lines: []string{""}}
+}
@@ -12,7 +12,12 @@ import (
 
 const peopleFile = "people.json"
 
-func (s *Store) SavePerson(person *core.Person) error {
+func (s *FileStore) SavePerson(person *core.Person) error {
+	return s.Update(func() error { return s.savePerson(person) })
+}
+
+func (s *FileStore) savePerson(person *core.Person) error {
+	captureRecord(&s.base, person.ID, s.GetPerson)
 	people, err := s.loadPeople()
 	if err != nil {
 		return err
@@ -34,7 +39,27 @@ func (s *Store) SavePerson(person *core.Person) error {
 	return s.savePeople(people)
 }
 
-func (s *Store) GetPerson(id string) (*core.Person, error) {
+// DeletePerson removes a person. Removing one that doesn't exist does
+// nothing.
+func (s *FileStore) DeletePerson(id string) error {
+	captureRecord(&s.base, id, s.GetPerson)
+	return s.Update(func() error {
+		people, err := s.loadPeople()
+		if err != nil {
+			return err
+		}
+
+		kept := people[:0]
+		for _, p := range people {
+			if p.ID != id {
+				kept = append(kept, p)
+			}
+		}
+		return s.savePeople(kept)
+	})
+}
+
+func (s *FileStore) GetPerson(id string) (*core.Person, error) {
 	people, err := s.loadPeople()
 	if err != nil {
 		return nil, err
@@ -49,11 +74,11 @@ func (s *Store) GetPerson(id string) (*core.Person, error) {
 	return nil, fmt.Errorf("person not found: %s", id)
 }
 
-func (s *Store) ListPeople() ([]*core.Person, error) {
+func (s *FileStore) ListPeople() ([]*core.Person, error) {
 	return s.loadPeople()
 }
 
-func (s *Store) FindPersonByName(name string) (*core.Person, error) {
+func (s *FileStore) FindPersonByName(name string) (*core.Person, error) {
 	people, err := s.loadPeople()
 	if err != nil {
 		return nil, err
@@ -70,7 +95,7 @@ func (s *Store) FindPersonByName(name string) (*core.Person, error) {
 	return nil, fmt.Errorf("person not found: %s", name)
 }
 
-func (s *Store) loadPeople() ([]*core.Person, error) {
+func (s *FileStore) loadPeople() ([]*core.Person, error) {
 	filename := filepath.Join(s.MetaDir(), peopleFile)
 	data, err := os.ReadFile(filename)
 	if os.IsNotExist(err) {
@@ -92,12 +117,12 @@ func (s *Store) loadPeople() ([]*core.Person, error) {
 	return people, nil
 }
 
-func (s *Store) savePeople(people []*core.Person) error {
+func (s *FileStore) savePeople(people []*core.Person) error {
 	filename := filepath.Join(s.MetaDir(), peopleFile)
 	data, err := json.MarshalIndent(people, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal people: %w", err)
 	}
 
-	return os.WriteFile(filename, data, 0644)
+	return writeFileAtomic(filename, data, 0644)
 }
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+)
+
+// progressFile holds the progress reported on projects
+const progressFile = "progress.json"
+
+func (s *FileStore) SaveProgress(progress *core.Progress) error {
+	captureRecord(&s.base, progress.ID, s.GetProgress)
+	return s.Update(func() error {
+		records, err := s.loadProgress()
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for i, existing := range records {
+			if existing.ID == progress.ID {
+				records[i] = progress
+				found = true
+				break
+			}
+		}
+		if !found {
+			records = append(records, progress)
+		}
+		return s.saveProgress(records)
+	})
+}
+
+// DeleteProgress removes a progress record. Removing one that doesn't exist
+// does nothing.
+func (s *FileStore) DeleteProgress(id string) error {
+	captureRecord(&s.base, id, s.GetProgress)
+	return s.Update(func() error {
+		records, err := s.loadProgress()
+		if err != nil {
+			return err
+		}
+
+		kept := records[:0]
+		for _, p := range records {
+			if p.ID != id {
+				kept = append(kept, p)
+			}
+		}
+		return s.saveProgress(kept)
+	})
+}
+
+func (s *FileStore) GetProgress(id string) (*core.Progress, error) {
+	records, err := s.loadProgress()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range records {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("progress not found: %s", id)
+}
+
+// ListProgress returns every progress record, oldest first
+func (s *FileStore) ListProgress() ([]*core.Progress, error) {
+	records, err := s.loadProgress()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	return records, nil
+}
+
+// ListProgressByProject returns the progress reported on a project, oldest
+// first
+func (s *FileStore) ListProgressByProject(projectID string) ([]*core.Progress, error) {
+	return s.filterProgress(func(p *core.Progress) bool { return p.ProjectID == projectID })
+}
+
+// ListProgressBetween returns the progress reported in [from, to), oldest
+// first
+func (s *FileStore) ListProgressBetween(from, to time.Time) ([]*core.Progress, error) {
+	return s.filterProgress(func(p *core.Progress) bool {
+		return !p.Timestamp.Before(from) && p.Timestamp.Before(to)
+	})
+}
+
+func (s *FileStore) filterProgress(keep func(*core.Progress) bool) ([]*core.Progress, error) {
+	records, err := s.ListProgress()
+	if err != nil {
+		return nil, err
+	}
+
+	var kept []*core.Progress
+	for _, p := range records {
+		if keep(p) {
+			kept = append(kept, p)
+		}
+	}
+	return kept, nil
+}
+
+func (s *FileStore) loadProgress() ([]*core.Progress, error) {
+	data, err := os.ReadFile(filepath.Join(s.MetaDir(), progressFile))
+	if os.IsNotExist(err) {
+		return []*core.Progress{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*core.Progress{}
+	if len(data) == 0 {
+		return records, nil
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal progress: %w", err)
+	}
+	return records, nil
+}
+
+func (s *FileStore) saveProgress(records []*core.Progress) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %w", err)
+	}
+	return writeFileAtomic(filepath.Join(s.MetaDir(), progressFile), data, 0644)
+}
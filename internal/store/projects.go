@@ -11,7 +11,12 @@ import (
 
 const projectsFile = "projects.json"
 
-func (s *Store) SaveProject(project *core.Project) error {
+func (s *FileStore) SaveProject(project *core.Project) error {
+	return s.Update(func() error { return s.saveProject(project) })
+}
+
+func (s *FileStore) saveProject(project *core.Project) error {
+	captureRecord(&s.base, project.ID, s.GetProject)
 	projects, err := s.loadProjects()
 	if err != nil {
 		return err
@@ -33,7 +38,27 @@ func (s *Store) SaveProject(project *core.Project) error {
 	return s.saveProjects(projects)
 }
 
-func (s *Store) GetProject(id string) (*core.Project, error) {
+// DeleteProject removes a project. Removing one that doesn't exist does
+// nothing.
+func (s *FileStore) DeleteProject(id string) error {
+	captureRecord(&s.base, id, s.GetProject)
+	return s.Update(func() error {
+		projects, err := s.loadProjects()
+		if err != nil {
+			return err
+		}
+
+		kept := projects[:0]
+		for _, p := range projects {
+			if p.ID != id {
+				kept = append(kept, p)
+			}
+		}
+		return s.saveProjects(kept)
+	})
+}
+
+func (s *FileStore) GetProject(id string) (*core.Project, error) {
 	projects, err := s.loadProjects()
 	if err != nil {
 		return nil, err
@@ -48,11 +73,11 @@ func (s *Store) GetProject(id string) (*core.Project, error) {
 	return nil, fmt.Errorf("project not found: %s", id)
 }
 
-func (s *Store) ListProjects() ([]*core.Project, error) {
+func (s *FileStore) ListProjects() ([]*core.Project, error) {
 	return s.loadProjects()
 }
 
-func (s *Store) loadProjects() ([]*core.Project, error) {
+func (s *FileStore) loadProjects() ([]*core.Project, error) {
 	filename := filepath.Join(s.MetaDir(), projectsFile)
 	data, err := os.ReadFile(filename)
 	if os.IsNotExist(err) {
@@ -74,12 +99,12 @@ func (s *Store) loadProjects() ([]*core.Project, error) {
 	return projects, nil
 }
 
-func (s *Store) saveProjects(projects []*core.Project) error {
+func (s *FileStore) saveProjects(projects []*core.Project) error {
 	filename := filepath.Join(s.MetaDir(), projectsFile)
 	data, err := json.MarshalIndent(projects, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal projects: %w", err)
 	}
 
-	return os.WriteFile(filename, data, 0644)
+	return writeFileAtomic(filename, data, 0644)
 }
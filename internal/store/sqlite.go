@@ -0,0 +1,1650 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+	_ "modernc.org/sqlite"
+)
+
+const sqliteFile = "grechen.db"
+
+// Times are stored as unix nanoseconds so range queries can use the indexes;
+// a NULL deadline means the commitment has none.
+const schema = `
+CREATE TABLE IF NOT EXISTS people (
+	id         TEXT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	name_lower TEXT NOT NULL,
+	metadata   TEXT
+);
+CREATE INDEX IF NOT EXISTS people_name ON people(name_lower);
+
+CREATE TABLE IF NOT EXISTS projects (
+	id       TEXT PRIMARY KEY,
+	priority INTEGER NOT NULL,
+	metadata TEXT
+);
+
+CREATE TABLE IF NOT EXISTS commitments (
+	id             TEXT PRIMARY KEY,
+	created_at     INTEGER NOT NULL,
+	source_entry   TEXT NOT NULL,
+	person_id      TEXT NOT NULL,
+	project_id     TEXT NOT NULL,
+	description    TEXT NOT NULL,
+	deadline       INTEGER,
+	hardness       TEXT NOT NULL,
+	status         TEXT NOT NULL,
+	last_update_at INTEGER,
+	slips          INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS commitments_person ON commitments(person_id);
+CREATE INDEX IF NOT EXISTS commitments_project ON commitments(project_id);
+CREATE INDEX IF NOT EXISTS commitments_deadline ON commitments(status, deadline);
+CREATE INDEX IF NOT EXISTS commitments_created ON commitments(status, created_at);
+
+CREATE TABLE IF NOT EXISTS commitment_events (
+	commitment_id TEXT NOT NULL REFERENCES commitments(id),
+	seq           INTEGER NOT NULL,
+	at            INTEGER NOT NULL,
+	type          TEXT NOT NULL,
+	description   TEXT NOT NULL,
+	entry_id      TEXT NOT NULL DEFAULT '',
+	deadline      INTEGER,
+	PRIMARY KEY (commitment_id, seq)
+);
+CREATE INDEX IF NOT EXISTS commitment_events_at ON commitment_events(at);
+
+CREATE TABLE IF NOT EXISTS journal (
+	seq           INTEGER PRIMARY KEY AUTOINCREMENT,
+	at            INTEGER NOT NULL,
+	type          TEXT NOT NULL,
+	commitment_id TEXT NOT NULL,
+	commitment    TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS journal_at ON journal(at);
+
+CREATE TABLE IF NOT EXISTS entry_log (
+	id         TEXT PRIMARY KEY,
+	at         INTEGER NOT NULL,
+	raw        TEXT NOT NULL,
+	source     TEXT NOT NULL,
+	confidence REAL NOT NULL,
+	candidates TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS inbox (
+	id         TEXT PRIMARY KEY,
+	created_at INTEGER NOT NULL,
+	entry_id   TEXT NOT NULL,
+	candidate  TEXT NOT NULL,
+	questions  TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS events (
+	id           TEXT PRIMARY KEY,
+	created_at   INTEGER NOT NULL,
+	source_entry TEXT NOT NULL,
+	title        TEXT NOT NULL,
+	start_at     INTEGER NOT NULL,
+	end_at       INTEGER,
+	all_day      INTEGER NOT NULL,
+	attendees    TEXT NOT NULL,
+	project_id   TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS events_start ON events(start_at);
+
+CREATE TABLE IF NOT EXISTS progress (
+	id           TEXT PRIMARY KEY,
+	at           INTEGER NOT NULL,
+	source_entry TEXT NOT NULL,
+	project_id   TEXT NOT NULL,
+	status       TEXT NOT NULL,
+	notes        TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS progress_project ON progress(project_id, at);
+CREATE INDEX IF NOT EXISTS progress_at ON progress(at);
+
+CREATE TABLE IF NOT EXISTS aliases (
+	alias TEXT PRIMARY KEY,
+	kind  TEXT NOT NULL,
+	id    TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS entries (
+	day     TEXT NOT NULL,
+	seq     INTEGER NOT NULL,
+	section TEXT NOT NULL,
+	at      INTEGER,
+	text    TEXT NOT NULL,
+	PRIMARY KEY (day, seq)
+);
+CREATE INDEX IF NOT EXISTS entries_section ON entries(section, day);
+`
+
+// SQLiteStore keeps people, projects, commitments and their events in an
+// indexed SQLite database at meta/grechen.db. Commitment changes are appended
+// to the journal table in the same transaction that updates the commitments
+// table, which holds their projection. The daily markdown files stay the
+// human-readable record; their entries are indexed in the database as
+// they're written.
+type SQLiteStore struct {
+	base
+	db *sql.DB
+}
+
+func NewSQLiteStore(dataDir string, loc *time.Location) (*SQLiteStore, error) {
+	b, err := newBase(dataDir, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := openDB(filepath.Join(b.MetaDir(), sqliteFile))
+	if err != nil {
+		return nil, err
+	}
+	return &SQLiteStore{base: b, db: db}, nil
+}
+
+func openDB(filename string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", filename+"?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+	columns := []struct{ table, column, decl string }{
+		{"commitment_events", "entry_id", `TEXT NOT NULL DEFAULT ''`},
+		{"commitment_events", "deadline", `INTEGER`},
+		{"commitments", "slips", `INTEGER NOT NULL DEFAULT 0`},
+		{"commitment_events", "event_id", `TEXT NOT NULL DEFAULT ''`},
+	}
+	for _, c := range columns {
+		if err := addColumn(db, c.table, c.column, c.decl); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// addColumn adds a column that databases created by earlier versions lack
+func addColumn(db *sql.DB, table, column, decl string) error {
+	var exists bool
+	err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM pragma_table_info(?) WHERE name = ?)`, table, column).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s: %w", table, err)
+	}
+	if exists {
+		return nil
+	}
+	if _, err := db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + decl); err != nil {
+		return fmt.Errorf("failed to add %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Backend() string {
+	return "sqlite"
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// tx runs fn in a database transaction under the store lock
+func (s *SQLiteStore) tx(fn func(tx *sql.Tx) error) error {
+	return s.Update(func() error { return withTx(s.db, fn) })
+}
+
+func withTx(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) SaveCommitment(commitment *core.Commitment) error {
+	captureRecord(&s.base, commitment.ID, s.GetCommitment)
+	return s.Update(func() error {
+		var prev *core.Commitment
+		current, err := s.queryCommitments(`WHERE id = ?`, commitment.ID)
+		if err != nil {
+			return err
+		}
+		if len(current) > 0 {
+			prev = current[0]
+		}
+
+		events, err := s.idIndex(KindEvent)
+		if err != nil {
+			return err
+		}
+		if err := assignEventIDs(commitment, events); err != nil {
+			return err
+		}
+
+		eventType, ok := journalEventType(prev, commitment)
+		if !ok {
+			return nil
+		}
+
+		return withTx(s.db, func(tx *sql.Tx) error {
+			if err := s.seedJournal(tx); err != nil {
+				return err
+			}
+			event := JournalEvent{At: eventAt(eventType, commitment, s.Now()), Type: eventType, Commitment: commitment}
+			if err := insertJournalEvent(tx, event); err != nil {
+				return err
+			}
+			return upsertCommitment(tx, commitment)
+		})
+	})
+}
+
+// DeleteCommitment removes a commitment, recording its removal in the
+// journal. Removing one that doesn't exist does nothing.
+func (s *SQLiteStore) DeleteCommitment(id string) error {
+	captureRecord(&s.base, id, s.GetCommitment)
+	return s.Update(func() error {
+		current, err := s.queryCommitments(`WHERE id = ?`, id)
+		if err != nil || len(current) == 0 {
+			return err
+		}
+
+		return withTx(s.db, func(tx *sql.Tx) error {
+			if err := s.seedJournal(tx); err != nil {
+				return err
+			}
+			event := JournalEvent{At: s.Now(), Type: EventDeleted, Commitment: current[0]}
+			if err := insertJournalEvent(tx, event); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`DELETE FROM commitment_events WHERE commitment_id = ?`, id); err != nil {
+				return fmt.Errorf("failed to delete commitment history: %w", err)
+			}
+			if _, err := tx.Exec(`DELETE FROM commitments WHERE id = ?`, id); err != nil {
+				return fmt.Errorf("failed to delete commitment: %w", err)
+			}
+			return nil
+		})
+	})
+}
+
+// ListCommitmentsAt returns the commitments as they stood at t
+func (s *SQLiteStore) ListCommitmentsAt(t time.Time) ([]*core.Commitment, error) {
+	events, err := s.journalEvents(s.db)
+	if err != nil {
+		return nil, err
+	}
+	return projection(events, t), nil
+}
+
+// queryer is what journalEvents needs from a *sql.DB or *sql.Tx
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// journalEvents returns the journal events in the order they were appended.
+// Commitments recorded before the journal existed are described by events
+// reconstructed from the commitments table. Backdated events are out of time
+// order, so they're all read and projection sorts and filters them.
+func (s *SQLiteStore) journalEvents(q queryer) ([]JournalEvent, error) {
+	rows, err := q.Query(`SELECT at, type, commitment FROM journal ORDER BY seq`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query journal: %w", err)
+	}
+	defer rows.Close()
+
+	var events []JournalEvent
+	for rows.Next() {
+		var event JournalEvent
+		var at int64
+		var data string
+		if err := rows.Scan(&at, &event.Type, &data); err != nil {
+			return nil, fmt.Errorf("failed to read journal: %w", err)
+		}
+		event.At = s.fromUnix(at)
+		if err := json.Unmarshal([]byte(data), &event.Commitment); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal journal event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(events) > 0 {
+		return events, nil
+	}
+
+	var started bool
+	if err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM journal)`).Scan(&started); err != nil {
+		return nil, fmt.Errorf("failed to query journal: %w", err)
+	}
+	if started {
+		return nil, nil
+	}
+	commitments, err := s.ListCommitments()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range commitments {
+		events = append(events, seedEvents(c)...)
+	}
+	return events, nil
+}
+
+// seedJournal starts the journal for commitments recorded before it existed
+func (s *SQLiteStore) seedJournal(tx *sql.Tx) error {
+	var started bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM journal)`).Scan(&started); err != nil {
+		return fmt.Errorf("failed to query journal: %w", err)
+	}
+	if started {
+		return nil
+	}
+
+	events, err := s.journalEvents(tx)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := insertJournalEvent(tx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertJournalEvent(tx *sql.Tx, event JournalEvent) error {
+	data, err := json.Marshal(event.Commitment)
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal event: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO journal (at, type, commitment_id, commitment) VALUES (?, ?, ?, ?)`,
+		event.At.UnixNano(), event.Type, event.Commitment.ID, string(data)); err != nil {
+		return fmt.Errorf("failed to append to journal: %w", err)
+	}
+	return nil
+}
+
+// upsertCommitment writes c to the commitments projection
+func upsertCommitment(tx *sql.Tx, c *core.Commitment) error {
+	_, err := tx.Exec(`INSERT INTO commitments
+		(id, created_at, source_entry, person_id, project_id, description, deadline, hardness, status, last_update_at, slips)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			created_at = excluded.created_at, source_entry = excluded.source_entry,
+			person_id = excluded.person_id, project_id = excluded.project_id,
+			description = excluded.description, deadline = excluded.deadline,
+			hardness = excluded.hardness, status = excluded.status,
+			last_update_at = excluded.last_update_at, slips = excluded.slips`,
+		c.ID, c.CreatedAt.UnixNano(), c.SourceEntry, c.PersonID, c.ProjectID,
+		c.Expectation.Description, nullTime(c.Expectation.Deadline), c.Expectation.Hardness,
+		string(c.Status), nullTimePtr(c.LastUpdateAt), c.Slips)
+	if err != nil {
+		return fmt.Errorf("failed to save commitment: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM commitment_events WHERE commitment_id = ?`, c.ID); err != nil {
+		return fmt.Errorf("failed to save commitment history: %w", err)
+	}
+	for i, event := range c.History {
+		if _, err := tx.Exec(`INSERT INTO commitment_events (commitment_id, seq, event_id, at, type, description, entry_id, deadline) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			c.ID, i, event.ID, event.Timestamp.UnixNano(), event.Type, event.Description, event.EntryID, nullTimePtr(event.Deadline)); err != nil {
+			return fmt.Errorf("failed to save commitment history: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetCommitment(id string) (*core.Commitment, error) {
+	commitments, err := s.queryCommitments(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(commitments) == 0 {
+		return nil, fmt.Errorf("commitment not found: %s", id)
+	}
+	return commitments[0], nil
+}
+
+func (s *SQLiteStore) ListCommitments() ([]*core.Commitment, error) {
+	return s.queryCommitments(``)
+}
+
+func (s *SQLiteStore) ListOpenCommitments() ([]*core.Commitment, error) {
+	return s.queryCommitments(`WHERE status IN (?, ?)`, core.StatusOpen, core.StatusUpdated)
+}
+
+func (s *SQLiteStore) ListCommitmentsByPerson(personID string) ([]*core.Commitment, error) {
+	return s.queryCommitments(`WHERE person_id = ?`, personID)
+}
+
+func (s *SQLiteStore) ListCommitmentsByProject(projectID string) ([]*core.Commitment, error) {
+	return s.queryCommitments(`WHERE project_id = ?`, projectID)
+}
+
+func (s *SQLiteStore) ListCommitmentsDueBefore(deadline time.Time) ([]*core.Commitment, error) {
+	return s.queryCommitments(`WHERE status IN (?, ?) AND deadline < ?`,
+		core.StatusOpen, core.StatusUpdated, deadline.UnixNano())
+}
+
+// ListOpenCommitmentsFromPreviousDays returns open commitments created before today
+func (s *SQLiteStore) ListOpenCommitmentsFromPreviousDays(today time.Time) ([]*core.Commitment, error) {
+	todayStart := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	return s.queryCommitments(`WHERE status IN (?, ?) AND created_at < ?`,
+		core.StatusOpen, core.StatusUpdated, todayStart.UnixNano())
+}
+
+// queryCommitments loads the commitments matching where, in the order they
+// were first saved, along with their history
+func (s *SQLiteStore) queryCommitments(where string, args ...any) ([]*core.Commitment, error) {
+	rows, err := s.db.Query(`SELECT id, created_at, source_entry, person_id, project_id,
+		description, deadline, hardness, status, last_update_at, slips
+		FROM commitments `+where+` ORDER BY rowid`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query commitments: %w", err)
+	}
+	defer rows.Close()
+
+	commitments := []*core.Commitment{}
+	byID := make(map[string]*core.Commitment)
+	for rows.Next() {
+		var c core.Commitment
+		var createdAt int64
+		var deadline, lastUpdate sql.NullInt64
+		var status string
+		if err := rows.Scan(&c.ID, &createdAt, &c.SourceEntry, &c.PersonID, &c.ProjectID,
+			&c.Expectation.Description, &deadline, &c.Expectation.Hardness, &status, &lastUpdate, &c.Slips); err != nil {
+			return nil, fmt.Errorf("failed to read commitment: %w", err)
+		}
+		c.CreatedAt = s.fromUnix(createdAt)
+		if deadline.Valid {
+			c.Expectation.Deadline = s.fromUnix(deadline.Int64)
+		}
+		if lastUpdate.Valid {
+			t := s.fromUnix(lastUpdate.Int64)
+			c.LastUpdateAt = &t
+		}
+		c.Status = core.CommitmentStatus(status)
+		commitments = append(commitments, &c)
+		byID[c.ID] = &c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(commitments) == 0 {
+		return commitments, nil
+	}
+
+	if err := s.loadHistory(byID); err != nil {
+		return nil, err
+	}
+	return commitments, nil
+}
+
+func (s *SQLiteStore) loadHistory(byID map[string]*core.Commitment) error {
+	ids := make([]any, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+
+	rows, err := s.db.Query(`SELECT commitment_id, event_id, at, type, description, entry_id, deadline FROM commitment_events
+		WHERE commitment_id IN (`+placeholders+`) ORDER BY commitment_id, seq`, ids...)
+	if err != nil {
+		return fmt.Errorf("failed to query commitment history: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, eventID, eventType, description, entryID string
+		var at int64
+		var deadline sql.NullInt64
+		if err := rows.Scan(&id, &eventID, &at, &eventType, &description, &entryID, &deadline); err != nil {
+			return fmt.Errorf("failed to read commitment history: %w", err)
+		}
+		event := core.CommitmentEvent{
+			ID:          eventID,
+			Timestamp:   s.fromUnix(at),
+			Type:        eventType,
+			Description: description,
+			EntryID:     entryID,
+		}
+		if deadline.Valid {
+			t := s.fromUnix(deadline.Int64)
+			event.Deadline = &t
+		}
+		c := byID[id]
+		c.History = append(c.History, event)
+	}
+	return rows.Err()
+}
+
+func (s *SQLiteStore) SavePending(item *core.PendingItem) error {
+	captureRecord(&s.base, item.ID, s.GetPending)
+	return s.tx(func(tx *sql.Tx) error { return upsertPending(tx, item) })
+}
+
+func upsertPending(tx *sql.Tx, item *core.PendingItem) error {
+	candidate, err := json.Marshal(item.Candidate)
+	if err != nil {
+		return fmt.Errorf("failed to marshal candidate: %w", err)
+	}
+	questions, err := json.Marshal(item.Questions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal questions: %w", err)
+	}
+	_, err = tx.Exec(`INSERT INTO inbox (id, created_at, entry_id, candidate, questions) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET candidate = excluded.candidate, questions = excluded.questions`,
+		item.ID, item.CreatedAt.UnixNano(), item.EntryID, string(candidate), string(questions))
+	if err != nil {
+		return fmt.Errorf("failed to save inbox item: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetPending(id string) (*core.PendingItem, error) {
+	items, err := s.queryPending(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("inbox item not found: %s", id)
+	}
+	return items[0], nil
+}
+
+func (s *SQLiteStore) ListPending() ([]*core.PendingItem, error) {
+	return s.queryPending(``)
+}
+
+func (s *SQLiteStore) DeletePending(id string) error {
+	captureRecord(&s.base, id, s.GetPending)
+	return s.tx(func(tx *sql.Tx) error {
+		res, err := tx.Exec(`DELETE FROM inbox WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete inbox item: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("inbox item not found: %s", id)
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) queryPending(where string, args ...any) ([]*core.PendingItem, error) {
+	rows, err := s.db.Query(`SELECT id, created_at, entry_id, candidate, questions FROM inbox `+where+` ORDER BY created_at`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query inbox: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*core.PendingItem{}
+	for rows.Next() {
+		var item core.PendingItem
+		var createdAt int64
+		var candidate, questions string
+		if err := rows.Scan(&item.ID, &createdAt, &item.EntryID, &candidate, &questions); err != nil {
+			return nil, fmt.Errorf("failed to read inbox: %w", err)
+		}
+		item.CreatedAt = s.fromUnix(createdAt)
+		if err := json.Unmarshal([]byte(candidate), &item.Candidate); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal candidate: %w", err)
+		}
+		if err := json.Unmarshal([]byte(questions), &item.Questions); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal questions: %w", err)
+		}
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
+
+func (s *SQLiteStore) SaveEvent(event *core.Event) error {
+	captureRecord(&s.base, event.ID, s.GetEvent)
+	return s.tx(func(tx *sql.Tx) error { return upsertEvent(tx, event) })
+}
+
+func upsertEvent(tx *sql.Tx, e *core.Event) error {
+	attendees, err := json.Marshal(e.Attendees)
+	if err != nil {
+		return fmt.Errorf("failed to marshal attendees: %w", err)
+	}
+	_, err = tx.Exec(`INSERT INTO events (id, created_at, source_entry, title, start_at, end_at, all_day, attendees, project_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET title = excluded.title, start_at = excluded.start_at, end_at = excluded.end_at,
+			all_day = excluded.all_day, attendees = excluded.attendees, project_id = excluded.project_id`,
+		e.ID, e.CreatedAt.UnixNano(), e.SourceEntry, e.Title, e.Start.UnixNano(), nullTimePtr(e.End),
+		e.AllDay, string(attendees), e.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to save event: %w", err)
+	}
+	return nil
+}
+
+// DeleteEvent removes an event. Removing one that doesn't exist does
+// nothing.
+func (s *SQLiteStore) DeleteEvent(id string) error {
+	captureRecord(&s.base, id, s.GetEvent)
+	return s.tx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM events WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete event: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) GetEvent(id string) (*core.Event, error) {
+	events, err := s.queryEvents(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("event not found: %s", id)
+	}
+	return events[0], nil
+}
+
+// ListEvents returns every event, by start time
+func (s *SQLiteStore) ListEvents() ([]*core.Event, error) {
+	return s.queryEvents(``)
+}
+
+// ListEventsBetween returns the events that start in [from, to) or are
+// still going on at from, by start time
+func (s *SQLiteStore) ListEventsBetween(from, to time.Time) ([]*core.Event, error) {
+	return s.queryEvents(`WHERE start_at < ? AND (start_at >= ? OR end_at > ?)`,
+		to.UnixNano(), from.UnixNano(), from.UnixNano())
+}
+
+func (s *SQLiteStore) queryEvents(where string, args ...any) ([]*core.Event, error) {
+	rows, err := s.db.Query(`SELECT id, created_at, source_entry, title, start_at, end_at, all_day, attendees, project_id
+		FROM events `+where+` ORDER BY start_at, rowid`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*core.Event{}
+	for rows.Next() {
+		var e core.Event
+		var createdAt, start int64
+		var end sql.NullInt64
+		var attendees string
+		if err := rows.Scan(&e.ID, &createdAt, &e.SourceEntry, &e.Title, &start, &end, &e.AllDay, &attendees, &e.ProjectID); err != nil {
+			return nil, fmt.Errorf("failed to read event: %w", err)
+		}
+		e.CreatedAt = s.fromUnix(createdAt)
+		e.Start = s.fromUnix(start)
+		if end.Valid {
+			t := s.fromUnix(end.Int64)
+			e.End = &t
+		}
+		if err := json.Unmarshal([]byte(attendees), &e.Attendees); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal attendees: %w", err)
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+func (s *SQLiteStore) SaveProgress(progress *core.Progress) error {
+	captureRecord(&s.base, progress.ID, s.GetProgress)
+	return s.tx(func(tx *sql.Tx) error { return upsertProgress(tx, progress) })
+}
+
+func upsertProgress(tx *sql.Tx, p *core.Progress) error {
+	_, err := tx.Exec(`INSERT INTO progress (id, at, source_entry, project_id, status, notes) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET at = excluded.at, project_id = excluded.project_id,
+			status = excluded.status, notes = excluded.notes`,
+		p.ID, p.Timestamp.UnixNano(), p.SourceEntry, p.ProjectID, p.Status, p.Notes)
+	if err != nil {
+		return fmt.Errorf("failed to save progress: %w", err)
+	}
+	return nil
+}
+
+// DeleteProgress removes a progress record. Removing one that doesn't exist
+// does nothing.
+func (s *SQLiteStore) DeleteProgress(id string) error {
+	captureRecord(&s.base, id, s.GetProgress)
+	return s.tx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM progress WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete progress: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) GetProgress(id string) (*core.Progress, error) {
+	records, err := s.queryProgress(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("progress not found: %s", id)
+	}
+	return records[0], nil
+}
+
+// ListProgress returns every progress record, oldest first
+func (s *SQLiteStore) ListProgress() ([]*core.Progress, error) {
+	return s.queryProgress(``)
+}
+
+// ListProgressByProject returns the progress reported on a project, oldest
+// first
+func (s *SQLiteStore) ListProgressByProject(projectID string) ([]*core.Progress, error) {
+	return s.queryProgress(`WHERE project_id = ?`, projectID)
+}
+
+// ListProgressBetween returns the progress reported in [from, to), oldest
+// first
+func (s *SQLiteStore) ListProgressBetween(from, to time.Time) ([]*core.Progress, error) {
+	return s.queryProgress(`WHERE at >= ? AND at < ?`, from.UnixNano(), to.UnixNano())
+}
+
+func (s *SQLiteStore) queryProgress(where string, args ...any) ([]*core.Progress, error) {
+	rows, err := s.db.Query(`SELECT id, at, source_entry, project_id, status, notes FROM progress `+where+` ORDER BY at, rowid`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query progress: %w", err)
+	}
+	defer rows.Close()
+
+	records := []*core.Progress{}
+	for rows.Next() {
+		var p core.Progress
+		var at int64
+		if err := rows.Scan(&p.ID, &at, &p.SourceEntry, &p.ProjectID, &p.Status, &p.Notes); err != nil {
+			return nil, fmt.Errorf("failed to read progress: %w", err)
+		}
+		p.Timestamp = s.fromUnix(at)
+		records = append(records, &p)
+	}
+	return records, rows.Err()
+}
+
+// SaveEntry records entry in the entry log
+func (s *SQLiteStore) SaveEntry(entry *core.Entry) error {
+	captureRecord(&s.base, entry.ID, s.GetEntry)
+	return s.tx(func(tx *sql.Tx) error { return insertEntry(tx, entry) })
+}
+
+func insertEntry(tx *sql.Tx, entry *core.Entry) error {
+	candidates, err := json.Marshal(entry.Candidates)
+	if err != nil {
+		return fmt.Errorf("failed to marshal entry candidates: %w", err)
+	}
+	_, err = tx.Exec(`INSERT INTO entry_log (id, at, raw, source, confidence, candidates) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO NOTHING`,
+		entry.ID, entry.Timestamp.UnixNano(), entry.Raw, entry.Source, entry.Confidence, string(candidates))
+	if err != nil {
+		return fmt.Errorf("failed to save entry: %w", err)
+	}
+	return nil
+}
+
+// deleteEntry takes entry id out of the entry log, as when undo takes back
+// the input it came from
+func (s *SQLiteStore) deleteEntry(id string) error {
+	return s.tx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM entry_log WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete entry: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) GetEntry(id string) (*core.Entry, error) {
+	var entry core.Entry
+	var at int64
+	var candidates string
+	err := s.db.QueryRow(`SELECT id, at, raw, source, confidence, candidates FROM entry_log WHERE id = ?`, id).
+		Scan(&entry.ID, &at, &entry.Raw, &entry.Source, &entry.Confidence, &candidates)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("entry not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entry: %w", err)
+	}
+	entry.Timestamp = s.fromUnix(at)
+	if err := json.Unmarshal([]byte(candidates), &entry.Candidates); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal entry candidates: %w", err)
+	}
+	return &entry, nil
+}
+
+// ListEntries returns the entry log, oldest first
+func (s *SQLiteStore) ListEntries() ([]*core.Entry, error) {
+	rows, err := s.db.Query(`SELECT id, at, raw, source, confidence, candidates FROM entry_log ORDER BY at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*core.Entry
+	for rows.Next() {
+		var entry core.Entry
+		var at int64
+		var candidates string
+		if err := rows.Scan(&entry.ID, &at, &entry.Raw, &entry.Source, &entry.Confidence, &candidates); err != nil {
+			return nil, fmt.Errorf("failed to scan entry: %w", err)
+		}
+		entry.Timestamp = s.fromUnix(at)
+		if err := json.Unmarshal([]byte(candidates), &entry.Candidates); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal entry candidates: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
+
+func (s *SQLiteStore) SavePerson(person *core.Person) error {
+	captureRecord(&s.base, person.ID, s.GetPerson)
+	return s.tx(func(tx *sql.Tx) error { return insertPerson(tx, person) })
+}
+
+func insertPerson(tx *sql.Tx, p *core.Person) error {
+	metadata, err := marshalMetadata(p.Metadata)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO people (id, name, name_lower, metadata) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name = excluded.name, name_lower = excluded.name_lower, metadata = excluded.metadata`,
+		p.ID, p.Name, strings.ToLower(p.Name), metadata)
+	if err != nil {
+		return fmt.Errorf("failed to save person: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) DeletePerson(id string) error {
+	captureRecord(&s.base, id, s.GetPerson)
+	return s.tx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM people WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete person: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) GetPerson(id string) (*core.Person, error) {
+	people, err := s.queryPeople(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(people) == 0 {
+		return nil, fmt.Errorf("person not found: %s", id)
+	}
+	return people[0], nil
+}
+
+func (s *SQLiteStore) ListPeople() ([]*core.Person, error) {
+	return s.queryPeople(``)
+}
+
+func (s *SQLiteStore) FindPersonByName(name string) (*core.Person, error) {
+	nameLower := strings.ToLower(name)
+	people, err := s.queryPeople(`WHERE name_lower = ? OR lower(id) = ?`, nameLower, nameLower)
+	if err != nil {
+		return nil, err
+	}
+	if len(people) == 0 {
+		return nil, fmt.Errorf("person not found: %s", name)
+	}
+	return people[0], nil
+}
+
+func (s *SQLiteStore) queryPeople(where string, args ...any) ([]*core.Person, error) {
+	rows, err := s.db.Query(`SELECT id, name, metadata FROM people `+where+` ORDER BY rowid`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query people: %w", err)
+	}
+	defer rows.Close()
+
+	people := []*core.Person{}
+	for rows.Next() {
+		var p core.Person
+		var metadata sql.NullString
+		if err := rows.Scan(&p.ID, &p.Name, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to read person: %w", err)
+		}
+		if p.Metadata, err = unmarshalMetadata(metadata); err != nil {
+			return nil, err
+		}
+		people = append(people, &p)
+	}
+	return people, rows.Err()
+}
+
+func (s *SQLiteStore) SaveProject(project *core.Project) error {
+	captureRecord(&s.base, project.ID, s.GetProject)
+	return s.tx(func(tx *sql.Tx) error { return insertProject(tx, project) })
+}
+
+func insertProject(tx *sql.Tx, p *core.Project) error {
+	metadata, err := marshalMetadata(p.Metadata)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO projects (id, priority, metadata) VALUES (?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET priority = excluded.priority, metadata = excluded.metadata`,
+		p.ID, p.Priority, metadata)
+	if err != nil {
+		return fmt.Errorf("failed to save project: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) DeleteProject(id string) error {
+	captureRecord(&s.base, id, s.GetProject)
+	return s.tx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM projects WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete project: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) GetProject(id string) (*core.Project, error) {
+	projects, err := s.queryProjects(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("project not found: %s", id)
+	}
+	return projects[0], nil
+}
+
+func (s *SQLiteStore) ListProjects() ([]*core.Project, error) {
+	return s.queryProjects(``)
+}
+
+func (s *SQLiteStore) queryProjects(where string, args ...any) ([]*core.Project, error) {
+	rows, err := s.db.Query(`SELECT id, priority, metadata FROM projects `+where+` ORDER BY rowid`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %w", err)
+	}
+	defer rows.Close()
+
+	projects := []*core.Project{}
+	for rows.Next() {
+		var p core.Project
+		var metadata sql.NullString
+		if err := rows.Scan(&p.ID, &p.Priority, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to read project: %w", err)
+		}
+		if p.Metadata, err = unmarshalMetadata(metadata); err != nil {
+			return nil, err
+		}
+		projects = append(projects, &p)
+	}
+	return projects, rows.Err()
+}
+
+// AppendLog, AppendCommitment, AppendNote and the rest write the daily file
+// as the JSON backend does, then re-index that day's entries
+
+func (s *SQLiteStore) AppendLog(date time.Time, entry *core.Entry) error {
+	return s.Update(func() error {
+		if err := s.base.AppendLog(date, entry); err != nil {
+			return err
+		}
+		return s.reindexDay(date)
+	})
+}
+
+func (s *SQLiteStore) AppendCommitment(date time.Time, commitment *core.Commitment) error {
+	return s.Update(func() error {
+		if err := s.base.AppendCommitment(date, commitment); err != nil {
+			return err
+		}
+		return s.reindexDay(date)
+	})
+}
+
+func (s *SQLiteStore) AppendNote(date time.Time, text string) error {
+	return s.Update(func() error {
+		if err := s.base.AppendNote(date, text); err != nil {
+			return err
+		}
+		return s.reindexDay(date)
+	})
+}
+
+func (s *SQLiteStore) ReplaceLog(date time.Time, entry *core.Entry, raw string) error {
+	return s.Update(func() error {
+		if err := s.base.ReplaceLog(date, entry, raw); err != nil {
+			return err
+		}
+		return s.reindexDay(date)
+	})
+}
+
+func (s *SQLiteStore) ReplaceCommitment(date time.Time, old, commitment *core.Commitment) error {
+	return s.Update(func() error {
+		if err := s.base.ReplaceCommitment(date, old, commitment); err != nil {
+			return err
+		}
+		return s.reindexDay(date)
+	})
+}
+
+func (s *SQLiteStore) WriteDay(doc *Doc) error {
+	return s.Update(func() error {
+		if err := s.base.WriteDay(doc); err != nil {
+			return err
+		}
+		return s.reindexDay(doc.Day)
+	})
+}
+
+func (s *SQLiteStore) ListDailyEntries(date time.Time) ([]DailyEntry, error) {
+	rows, err := s.db.Query(`SELECT section, at, text FROM entries WHERE day = ? ORDER BY seq`,
+		s.Day(date).Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []DailyEntry
+	for rows.Next() {
+		var entry DailyEntry
+		var at sql.NullInt64
+		if err := rows.Scan(&entry.Section, &at, &entry.Text); err != nil {
+			return nil, fmt.Errorf("failed to read entry: %w", err)
+		}
+		if at.Valid {
+			t := s.fromUnix(at.Int64)
+			entry.Time = &t
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// reindexDay replaces a day's indexed entries with what its file now holds
+func (s *SQLiteStore) reindexDay(date time.Time) error {
+	entries, err := s.base.ListDailyEntries(date)
+	if err != nil {
+		return err
+	}
+	return withTx(s.db, func(tx *sql.Tx) error {
+		return insertEntries(tx, s.Day(date), entries)
+	})
+}
+
+// reindexAll rebuilds the entry index from every daily file
+func (s *SQLiteStore) reindexAll() error {
+	return withTx(s.db, func(tx *sql.Tx) error {
+		_, err := s.importDailyFiles(tx)
+		return err
+	})
+}
+
+// importDailyFiles indexes every daily file, returning how many entries
+// were indexed
+func (s *base) importDailyFiles(tx *sql.Tx) (int, error) {
+	if _, err := tx.Exec(`DELETE FROM entries`); err != nil {
+		return 0, fmt.Errorf("failed to clear entries: %w", err)
+	}
+
+	days, err := s.Days()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, day := range days {
+		entries, err := s.ListDailyEntries(day)
+		if err != nil {
+			return 0, err
+		}
+		if err := insertEntries(tx, day, entries); err != nil {
+			return 0, err
+		}
+		count += len(entries)
+	}
+	return count, nil
+}
+
+func insertEntries(tx *sql.Tx, day time.Time, entries []DailyEntry) error {
+	key := day.Format("2006-01-02")
+	if _, err := tx.Exec(`DELETE FROM entries WHERE day = ?`, key); err != nil {
+		return fmt.Errorf("failed to index entries: %w", err)
+	}
+	for i, entry := range entries {
+		var at sql.NullInt64
+		if entry.Time != nil {
+			at = sql.NullInt64{Int64: entry.Time.UnixNano(), Valid: true}
+		}
+		if _, err := tx.Exec(`INSERT INTO entries (day, seq, section, at, text) VALUES (?, ?, ?, ?, ?)`,
+			key, i, entry.Section, at, entry.Text); err != nil {
+			return fmt.Errorf("failed to index entries: %w", err)
+		}
+	}
+	return nil
+}
+
+// HasData reports whether anything has been recorded yet
+func (s *SQLiteStore) HasData() (bool, error) {
+	if ok, err := s.hasDailyFiles(); ok || err != nil {
+		return ok, err
+	}
+
+	var n int
+	if err := s.db.QueryRow(`SELECT count(*) FROM commitments`).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to count commitments: %w", err)
+	}
+	return n > 0, nil
+}
+
+// PlanTimezoneMigration works out which log lines move when data that was
+// written with from's day boundaries and wall clock is re-read in the
+// store's zone. Nothing is written.
+func (s *SQLiteStore) PlanTimezoneMigration(from *time.Location) (*TimezoneMigration, error) {
+	shifts, err := s.planLogShifts(from)
+	if err != nil {
+		return nil, err
+	}
+	m := &TimezoneMigration{From: from, To: s.loc, Shifts: shifts}
+
+	commitments, err := s.ListCommitments()
+	if err != nil {
+		return nil, err
+	}
+	m.Deadlines = s.countUnanchored(commitments)
+
+	return m, nil
+}
+
+// ApplyTimezoneMigration moves log lines written in from to their day and
+// time in the store's zone, re-anchors deadlines and records the store's zone
+// as current
+func (s *SQLiteStore) ApplyTimezoneMigration(from *time.Location) (*TimezoneMigration, error) {
+	var m *TimezoneMigration
+	err := s.Update(func() error {
+		var err error
+		m, err = s.PlanTimezoneMigration(from)
+		if err != nil {
+			return err
+		}
+		if err := s.forgetOps(m); err != nil {
+			return err
+		}
+		if err := s.applyLogShifts(m.Shifts); err != nil {
+			return err
+		}
+		if len(m.Shifts) > 0 {
+			if err := s.reindexAll(); err != nil {
+				return err
+			}
+		}
+
+		if m.Deadlines > 0 {
+			commitments, err := s.ListCommitments()
+			if err != nil {
+				return err
+			}
+			for _, c := range commitments {
+				if !s.unanchored(c) {
+					continue
+				}
+				anchored := *c
+				anchored.Expectation.Deadline = s.anchorDate(c.Expectation.Deadline)
+				if err := s.SaveCommitment(&anchored); err != nil {
+					return err
+				}
+			}
+		}
+
+		return s.recordTimezone()
+	})
+	return m, err
+}
+
+// SQLiteMigration counts what MigrateToSQLite imported
+type SQLiteMigration struct {
+	People      int
+	Projects    int
+	Commitments int
+	Events      int
+	Inputs      int // entry log records
+	Entries     int // daily file lines
+	Pending     int // inbox items
+	Calendar    int // calendar events
+	Progress    int // progress records
+}
+
+// MigrateToSQLite imports the JSON files and daily entries into a new SQLite
+// database. The database is built under a temporary name and renamed into
+// place once complete, so an interrupted import leaves the JSON backend in
+// use. The JSON files are left in meta/ as a backup.
+func (s *FileStore) MigrateToSQLite() (*SQLiteMigration, error) {
+	var m *SQLiteMigration
+	err := s.Update(func() error {
+		filename := filepath.Join(s.MetaDir(), sqliteFile)
+		if _, err := os.Stat(filename); err == nil {
+			return fmt.Errorf("%s already exists", filename)
+		}
+
+		tmpName := filename + ".tmp"
+		os.Remove(tmpName)
+		defer os.Remove(tmpName) // no-op once renamed
+
+		db, err := openDB(tmpName)
+		if err != nil {
+			return err
+		}
+
+		m, err = s.importInto(db)
+		if closeErr := db.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := os.Rename(tmpName, filename); err != nil {
+			return fmt.Errorf("failed to move database into place: %w", err)
+		}
+		syncDir(s.MetaDir())
+		return nil
+	})
+	return m, err
+}
+
+func (s *FileStore) importInto(db *sql.DB) (*SQLiteMigration, error) {
+	people, err := s.loadPeople()
+	if err != nil {
+		return nil, err
+	}
+	projects, err := s.loadProjects()
+	if err != nil {
+		return nil, err
+	}
+	commitments, err := s.loadCommitments()
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.journalEvents()
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := s.loadAliases()
+	if err != nil {
+		return nil, err
+	}
+	inbox, err := s.loadInbox()
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := s.loadEvents()
+	if err != nil {
+		return nil, err
+	}
+	progress, err := s.loadProgress()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &SQLiteMigration{People: len(people), Projects: len(projects), Commitments: len(commitments), Events: len(events),
+		Pending: len(inbox), Calendar: len(calendar), Progress: len(progress)}
+	err = withTx(db, func(tx *sql.Tx) error {
+		for _, p := range people {
+			if err := insertPerson(tx, p); err != nil {
+				return err
+			}
+		}
+		for _, p := range projects {
+			if err := insertProject(tx, p); err != nil {
+				return err
+			}
+		}
+		for _, c := range commitments {
+			if err := upsertCommitment(tx, c); err != nil {
+				return err
+			}
+		}
+		for _, event := range events {
+			if err := insertJournalEvent(tx, event); err != nil {
+				return err
+			}
+		}
+		for _, item := range inbox {
+			if err := upsertPending(tx, item); err != nil {
+				return err
+			}
+		}
+		for _, e := range calendar {
+			if err := upsertEvent(tx, e); err != nil {
+				return err
+			}
+		}
+		for _, p := range progress {
+			if err := upsertProgress(tx, p); err != nil {
+				return err
+			}
+		}
+		for kind, byAlias := range aliases {
+			for alias, id := range byAlias {
+				if err := insertAlias(tx, kind, alias, id); err != nil {
+					return err
+				}
+			}
+		}
+		var insertErr error
+		err := s.eachEntry(func(entry *core.Entry) bool {
+			if insertErr = insertEntry(tx, entry); insertErr != nil {
+				return false
+			}
+			m.Inputs++
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if insertErr != nil {
+			return insertErr
+		}
+		m.Entries, err = s.importDailyFiles(tx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to import data: %w", err)
+	}
+	return m, nil
+}
+
+func (s *SQLiteStore) idIndex(kind string) (*idIndex, error) {
+	var query string
+	switch kind {
+	case KindCommitment:
+		query = `SELECT id FROM commitments`
+	case KindEntry:
+		query = `SELECT id FROM entry_log`
+	case KindEvent:
+		query = `SELECT event_id FROM commitment_events WHERE event_id != ''`
+	case KindPending:
+		query = `SELECT id FROM inbox`
+	case KindCalendar:
+		query = `SELECT id FROM events`
+	case KindProgress:
+		query = `SELECT id FROM progress`
+	default:
+		return nil, fmt.Errorf("unknown id kind %q", kind)
+	}
+
+	aliases, err := s.aliases(kind)
+	if err != nil {
+		return nil, err
+	}
+	index := newIDIndex(kind, aliases)
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ids: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to read ids: %w", err)
+		}
+		index.ids[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// IDs still held by the undo history and the commitment journal aren't
+	// given out again
+	if err := s.retireUndoIDs(index); err != nil {
+		return nil, err
+	}
+	if kind == KindCommitment || kind == KindEvent {
+		events, err := s.journalEvents(s.db)
+		if err != nil {
+			return nil, err
+		}
+		retireJournalIDs(index, events)
+	}
+	return index, nil
+}
+
+// aliases maps the aliases of kind to the IDs they stand for
+func (s *SQLiteStore) aliases(kind string) (map[string]string, error) {
+	rows, err := s.db.Query(`SELECT alias, id FROM aliases WHERE kind = ?`, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aliases: %w", err)
+	}
+	defer rows.Close()
+
+	aliases := map[string]string{}
+	for rows.Next() {
+		var alias, id string
+		if err := rows.Scan(&alias, &id); err != nil {
+			return nil, fmt.Errorf("failed to read aliases: %w", err)
+		}
+		aliases[alias] = id
+	}
+	return aliases, rows.Err()
+}
+
+// loadAliases maps each kind to its aliases and the IDs they stand for
+func (s *SQLiteStore) loadAliases() (map[string]map[string]string, error) {
+	rows, err := s.db.Query(`SELECT kind, alias, id FROM aliases`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aliases: %w", err)
+	}
+	defer rows.Close()
+
+	aliases := map[string]map[string]string{}
+	for rows.Next() {
+		var kind, alias, id string
+		if err := rows.Scan(&kind, &alias, &id); err != nil {
+			return nil, fmt.Errorf("failed to read aliases: %w", err)
+		}
+		if aliases[kind] == nil {
+			aliases[kind] = map[string]string{}
+		}
+		aliases[kind][alias] = id
+	}
+	return aliases, rows.Err()
+}
+
+// saveAliases replaces every alias with aliases
+func (s *SQLiteStore) saveAliases(aliases map[string]map[string]string) error {
+	if err := s.captureAliases(s.loadAliases); err != nil {
+		return err
+	}
+	return s.tx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM aliases`); err != nil {
+			return fmt.Errorf("failed to delete aliases: %w", err)
+		}
+		for kind, byAlias := range aliases {
+			for alias, id := range byAlias {
+				if err := insertAlias(tx, kind, alias, id); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func insertAlias(tx *sql.Tx, kind, alias, id string) error {
+	if _, err := tx.Exec(`INSERT INTO aliases (alias, kind, id) VALUES (?, ?, ?)`, alias, kind, id); err != nil {
+		return fmt.Errorf("failed to save alias: %w", err)
+	}
+	return nil
+}
+
+// NewID returns a short ID of kind that no record uses
+func (s *SQLiteStore) NewID(kind string) (string, error) {
+	index, err := s.idIndex(kind)
+	if err != nil {
+		return "", err
+	}
+	return index.newID()
+}
+
+// ClaimID returns id if no record of kind uses it yet, or else a new ID.
+// NewID runs before the store lock is taken, so another grechen process may
+// have used the same ID since; callers hold the lock.
+func (s *SQLiteStore) ClaimID(kind, id string) (string, error) {
+	index, err := s.idIndex(kind)
+	if err != nil {
+		return "", err
+	}
+	if !index.taken(id) {
+		return id, nil
+	}
+	return index.newID()
+}
+
+// ResolveID returns the ID of kind that idOrPrefix names: an ID, an alias,
+// or a prefix of only one of them
+func (s *SQLiteStore) ResolveID(kind, idOrPrefix string) (string, error) {
+	index, err := s.idIndex(kind)
+	if err != nil {
+		return "", err
+	}
+	return index.resolve(idOrPrefix)
+}
+
+// Aliases maps IDs of kind that have a short alias to that alias
+func (s *SQLiteStore) Aliases(kind string) (map[string]string, error) {
+	aliases, err := s.aliases(kind)
+	if err != nil {
+		return nil, err
+	}
+	return newIDIndex(kind, aliases).byID(), nil
+}
+
+// AssignAliases gives commitments and entries recorded before short IDs
+// existed a short alias, and their history events an ID
+func (s *SQLiteStore) AssignAliases() (*AliasMigration, error) {
+	m := &AliasMigration{}
+	err := s.Update(func() error {
+		var added [][3]string // kind, alias, ID
+		for _, kind := range []string{KindCommitment, KindEntry} {
+			index, err := s.idIndex(kind)
+			if err != nil {
+				return err
+			}
+			aliased := index.byID()
+			for id := range index.ids {
+				if isShortID(kind, id) || aliased[id] != "" {
+					continue
+				}
+				if err := index.alias(id); err != nil {
+					return err
+				}
+			}
+			for alias, id := range index.aliases {
+				if aliased[id] == "" {
+					added = append(added, [3]string{kind, alias, id})
+				}
+			}
+		}
+
+		if len(added) > 0 {
+			if err := s.captureAliases(s.loadAliases); err != nil {
+				return err
+			}
+		}
+		err := s.tx(func(tx *sql.Tx) error {
+			for _, a := range added {
+				if err := insertAlias(tx, a[0], a[1], a[2]); err != nil {
+					return err
+				}
+				if a[0] == KindCommitment {
+					m.Commitments++
+				} else {
+					m.Entries++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Saving fills in missing event IDs
+		commitments, err := s.ListCommitments()
+		if err != nil {
+			return err
+		}
+		for _, c := range commitments {
+			missing := countMissingEventIDs(c)
+			if missing == 0 {
+				continue
+			}
+			if err := s.SaveCommitment(c); err != nil {
+				return err
+			}
+			m.Events += missing
+		}
+		return nil
+	})
+	return m, err
+}
+
+func (s *SQLiteStore) fromUnix(ns int64) time.Time {
+	return time.Unix(0, ns).In(s.loc)
+}
+
+func nullTime(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixNano(), Valid: true}
+}
+
+func nullTimePtr(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return nullTime(*t)
+}
+
+func marshalMetadata(metadata map[string]any) (sql.NullString, error) {
+	if metadata == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func unmarshalMetadata(metadata sql.NullString) (map[string]any, error) {
+	if !metadata.Valid {
+		return nil, nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal([]byte(metadata.String), &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+	return m, nil
+}
@@ -4,21 +4,152 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
 )
 
-type Store struct {
-	dataDir string
+// Store is the persistence layer rules, stats, patterns and cli depend on.
+// Both backends write the same daily markdown files; they differ in where
+// people, projects and commitments live: FileStore keeps them in meta/*.json,
+// SQLiteStore in an indexed SQLite database.
+type Store interface {
+	// Backend names the implementation ("json" or "sqlite")
+	Backend() string
+
+	DataDir() string
+	DailyDir() string
+	MetaDir() string
+
+	// Location is the user's time zone; Now, Today and Day are computed in it
+	Location() *time.Location
+	Now() time.Time
+	Today() time.Time
+	Day(t time.Time) time.Time
+
+	// Update runs fn under the cross-process store lock
+	Update(fn func() error) error
+
+	SaveCommitment(commitment *core.Commitment) error
+	DeleteCommitment(id string) error
+	GetCommitment(id string) (*core.Commitment, error)
+	ListCommitments() ([]*core.Commitment, error)
+	ListOpenCommitments() ([]*core.Commitment, error)
+	ListCommitmentsByPerson(personID string) ([]*core.Commitment, error)
+	ListCommitmentsByProject(projectID string) ([]*core.Commitment, error)
+	ListCommitmentsDueBefore(deadline time.Time) ([]*core.Commitment, error)
+	ListOpenCommitmentsFromPreviousDays(today time.Time) ([]*core.Commitment, error)
+	ListCommitmentsAt(t time.Time) ([]*core.Commitment, error)
+
+	SaveEntry(entry *core.Entry) error
+	GetEntry(id string) (*core.Entry, error)
+	ListEntries() ([]*core.Entry, error)
+
+	// Calendar events
+	SaveEvent(event *core.Event) error
+	DeleteEvent(id string) error
+	GetEvent(id string) (*core.Event, error)
+	ListEvents() ([]*core.Event, error)
+	ListEventsBetween(from, to time.Time) ([]*core.Event, error)
+
+	// Progress reported on projects
+	SaveProgress(progress *core.Progress) error
+	DeleteProgress(id string) error
+	GetProgress(id string) (*core.Progress, error)
+	ListProgress() ([]*core.Progress, error)
+	ListProgressByProject(projectID string) ([]*core.Progress, error)
+	ListProgressBetween(from, to time.Time) ([]*core.Progress, error)
+
+	// Short IDs: NewID picks an unused one of a kind (KindCommitment,
+	// KindEntry, KindEvent, KindPending, KindCalendar, KindProgress), ResolveID turns an ID, alias or unique prefix
+	// into the full ID, and Aliases maps older long IDs to their alias.
+	// ClaimID, called under the store lock, returns an ID picked earlier if
+	// it's still unused and a new one if another process took it since.
+	NewID(kind string) (string, error)
+	ClaimID(kind, id string) (string, error)
+	ResolveID(kind, idOrPrefix string) (string, error)
+	Aliases(kind string) (map[string]string, error)
+	AssignAliases() (*AliasMigration, error)
+
+	// Inbox of candidates waiting for answers to their questions
+	SavePending(item *core.PendingItem) error
+	GetPending(id string) (*core.PendingItem, error)
+	ListPending() ([]*core.PendingItem, error)
+	DeletePending(id string) error
+
+	SavePerson(person *core.Person) error
+	DeletePerson(id string) error
+	GetPerson(id string) (*core.Person, error)
+	ListPeople() ([]*core.Person, error)
+	FindPersonByName(name string) (*core.Person, error)
+
+	SaveProject(project *core.Project) error
+	DeleteProject(id string) error
+	GetProject(id string) (*core.Project, error)
+	ListProjects() ([]*core.Project, error)
+
+	AppendLog(date time.Time, entry *core.Entry) error
+	AppendCommitment(date time.Time, commitment *core.Commitment) error
+	AppendNote(date time.Time, text string) error
+	ReplaceLog(date time.Time, entry *core.Entry, raw string) error
+	ReplaceCommitment(date time.Time, old, commitment *core.Commitment) error
+	ReadDay(date time.Time) (*Doc, error)
+	WriteDay(doc *Doc) error
+	Days() ([]time.Time, error)
+	ListDailyEntries(date time.Time) ([]DailyEntry, error)
+
+	// Record runs fn under the store lock as one operation: the records,
+	// inputs, aliases and daily files it changes are kept as they were
+	// before and after, for Undo to put back and Redo to make again. Undo and Redo return the
+	// operations they went through.
+	Record(label string, fn func() error) error
+	Undo(n int) ([]*Op, error)
+	Redo(n int) ([]*Op, error)
+
+	LoadInfo() (*Info, error)
+	SaveInfo(info *Info) error
+	HasData() (bool, error)
+	PlanTimezoneMigration(from *time.Location) (*TimezoneMigration, error)
+	ApplyTimezoneMigration(from *time.Location) (*TimezoneMigration, error)
 }
 
-func New(dataDir string) (*Store, error) {
-	s := &Store{dataDir: dataDir}
-	if err := s.init(); err != nil {
-		return nil, err
+// Open opens the store in dataDir, using the SQLite backend once the data
+// has been migrated to it and the JSON files otherwise. loc is the user's
+// time zone; it decides which daily file an entry lands in and how its HHMM
+// is written.
+func Open(dataDir string, loc *time.Location) (Store, error) {
+	if _, err := os.Stat(filepath.Join(dataDir, "meta", sqliteFile)); err == nil {
+		return NewSQLiteStore(dataDir, loc)
+	}
+	return NewFileStore(dataDir, loc)
+}
+
+var (
+	_ Store = (*FileStore)(nil)
+	_ Store = (*SQLiteStore)(nil)
+)
+
+// base holds what both backends share: the data directory layout, the
+// user's time zone, the process lock and the daily markdown files
+type base struct {
+	dataDir   string
+	loc       *time.Location
+	lockDepth int      // nesting depth of Update calls holding the lock
+	capture   *capture // daily files written by the operation being recorded
+}
+
+func newBase(dataDir string, loc *time.Location) (base, error) {
+	if loc == nil {
+		loc = time.Local
 	}
-	return s, nil
+	b := base{dataDir: dataDir, loc: loc}
+	if err := b.init(); err != nil {
+		return base{}, err
+	}
+	return b, nil
 }
 
-func (s *Store) init() error {
+func (s *base) init() error {
 	dirs := []string{
 		filepath.Join(s.dataDir, "daily"),
 		filepath.Join(s.dataDir, "meta"),
@@ -31,14 +162,52 @@ func (s *Store) init() error {
 	return nil
 }
 
-func (s *Store) DataDir() string {
+func (s *base) DataDir() string {
 	return s.dataDir
 }
 
-func (s *Store) DailyDir() string {
+func (s *base) DailyDir() string {
 	return filepath.Join(s.dataDir, "daily")
 }
 
-func (s *Store) MetaDir() string {
+func (s *base) MetaDir() string {
 	return filepath.Join(s.dataDir, "meta")
 }
+
+// Location returns the user's time zone
+func (s *base) Location() *time.Location {
+	return s.loc
+}
+
+// Now returns the current time in the user's time zone
+func (s *base) Now() time.Time {
+	return time.Now().In(s.loc)
+}
+
+// Today returns midnight of the current day in the user's time zone
+func (s *base) Today() time.Time {
+	return s.Day(time.Now())
+}
+
+// Day returns midnight of the day t falls on in the user's time zone
+func (s *base) Day(t time.Time) time.Time {
+	t = t.In(s.loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+}
+
+// FileStore keeps people, projects and commitments in JSON files under meta/
+type FileStore struct {
+	base
+}
+
+func NewFileStore(dataDir string, loc *time.Location) (*FileStore, error) {
+	b, err := newBase(dataDir, loc)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{base: b}, nil
+}
+
+func (s *FileStore) Backend() string {
+	return "json"
+}
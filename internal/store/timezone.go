@@ -0,0 +1,224 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+)
+
+// LogShift is a log line whose day or time changes once it's re-read in the
+// user's time zone
+type LogShift struct {
+	From time.Time // as written, in the old zone
+	To   time.Time // the same instant in the user's zone
+	Text string
+
+	file  string
+	block int      // index in the file's logs section
+	more  []string // lines the item carries on to
+}
+
+// TimezoneMigration describes what moving existing data from one zone to the
+// store's zone would change
+type TimezoneMigration struct {
+	From      *time.Location
+	To        *time.Location
+	Shifts    []LogShift
+	Deadlines int // deadlines re-anchored to midnight in the new zone
+}
+
+// PlanTimezoneMigration works out which log lines move when data that was
+// written with from's day boundaries and wall clock is re-read in the
+// store's zone. Nothing is written.
+func (s *FileStore) PlanTimezoneMigration(from *time.Location) (*TimezoneMigration, error) {
+	shifts, err := s.planLogShifts(from)
+	if err != nil {
+		return nil, err
+	}
+	m := &TimezoneMigration{From: from, To: s.loc, Shifts: shifts}
+
+	commitments, err := s.loadCommitments()
+	if err != nil {
+		return nil, err
+	}
+	m.Deadlines = s.countUnanchored(commitments)
+
+	return m, nil
+}
+
+// ApplyTimezoneMigration moves log lines written in from to their day and
+// time in the store's zone, re-anchors deadlines and records the store's zone
+// as current. The plan is redone under the lock so it matches the files.
+func (s *FileStore) ApplyTimezoneMigration(from *time.Location) (*TimezoneMigration, error) {
+	var m *TimezoneMigration
+	err := s.Update(func() error {
+		var err error
+		m, err = s.PlanTimezoneMigration(from)
+		if err != nil {
+			return err
+		}
+		if err := s.forgetOps(m); err != nil {
+			return err
+		}
+		if err := s.applyLogShifts(m.Shifts); err != nil {
+			return err
+		}
+
+		if m.Deadlines > 0 {
+			commitments, err := s.loadCommitments()
+			if err != nil {
+				return err
+			}
+			for _, c := range commitments {
+				if !s.unanchored(c) {
+					continue
+				}
+				anchored := *c
+				anchored.Expectation.Deadline = s.anchorDate(c.Expectation.Deadline)
+				if err := s.saveCommitment(&anchored); err != nil {
+					return err
+				}
+			}
+		}
+
+		return s.recordTimezone()
+	})
+	return m, err
+}
+
+// forgetOps drops the undo history when m moves anything. The recorded
+// operations hold daily files and deadlines as they were in the old zone,
+// so undoing or redoing one afterwards would put them back there.
+func (s *base) forgetOps(m *TimezoneMigration) error {
+	if len(m.Shifts) == 0 && m.Deadlines == 0 {
+		return nil
+	}
+	return s.saveOps(nil)
+}
+
+// planLogShifts lists the daily log lines written in from whose day or HHMM
+// differs in the store's zone
+func (s *base) planLogShifts(from *time.Location) ([]LogShift, error) {
+	var shifts []LogShift
+
+	files, err := filepath.Glob(filepath.Join(s.DailyDir(), "*.md"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	for _, filename := range files {
+		day, err := time.ParseInLocation("2006-01-02", strings.TrimSuffix(filepath.Base(filename), ".md"), from)
+		if err != nil {
+			continue // not a daily file
+		}
+
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		doc := ParseDoc(string(data), day)
+		logs := doc.Section(SectionLogs)
+		if logs == nil {
+			continue
+		}
+		for i, b := range logs.Blocks {
+			if b.Kind != BlockItem || b.Time == nil {
+				continue
+			}
+
+			written := *b.Time
+			moved := written.In(s.loc)
+			if moved.Format("2006-01-02 1504") == written.Format("2006-01-02 1504") {
+				continue
+			}
+
+			shifts = append(shifts, LogShift{
+				From:  written,
+				To:    moved,
+				Text:  b.Text,
+				file:  filename,
+				block: i,
+				more:  b.Lines[1:],
+			})
+		}
+	}
+
+	return shifts, nil
+}
+
+// applyLogShifts moves each shifted line to its new day and time
+func (s *base) applyLogShifts(shifts []LogShift) error {
+	// Drop moved lines from their old files first, then add them to their
+	// new ones, so a line moving within a file isn't removed twice
+	remove := make(map[string][]int)
+	for _, shift := range shifts {
+		remove[shift.file] = append(remove[shift.file], shift.block)
+	}
+
+	for filename, blocks := range remove {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		doc := ParseDoc(string(data), time.Time{})
+		logs := doc.Section(SectionLogs)
+		sort.Sort(sort.Reverse(sort.IntSlice(blocks)))
+		for _, i := range blocks {
+			logs.Replace(i, nil)
+		}
+		if err := s.writeDaily(filename, []byte(doc.String())); err != nil {
+			return fmt.Errorf("failed to rewrite %s: %w", filename, err)
+		}
+	}
+
+	for _, shift := range shifts {
+		item := NewTimedItem(shift.To, shift.Text)
+		item.Lines = append(item.Lines, shift.more...)
+		if err := s.insertIntoDay(shift.To, SectionLogs, item); err != nil {
+			return fmt.Errorf("failed to move log line: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// countUnanchored counts deadlines that aren't midnight in the store's zone
+func (s *base) countUnanchored(commitments []*core.Commitment) int {
+	n := 0
+	for _, c := range commitments {
+		if s.unanchored(c) {
+			n++
+		}
+	}
+	return n
+}
+
+// unanchored reports whether c has a deadline that isn't midnight in the
+// store's zone
+func (s *base) unanchored(c *core.Commitment) bool {
+	deadline := c.Expectation.Deadline
+	return !deadline.IsZero() && !deadline.Equal(s.anchorDate(deadline))
+}
+
+// recordTimezone marks the data as written in the store's zone
+func (s *base) recordTimezone() error {
+	info, err := s.LoadInfo()
+	if err != nil {
+		return err
+	}
+	info.Timezone = s.loc.String()
+	return s.saveInfo(info)
+}
+
+// anchorDate keeps a date's calendar day but moves it to midnight in the
+// store's zone. Deadlines are days, not instants.
+func (s *base) anchorDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+}
@@ -0,0 +1,557 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/heywinit/grechen/internal/core"
+)
+
+const (
+	// undoFile holds the recorded operations, oldest first, so undo and redo
+	// survive between runs
+	undoFile = "undo.json"
+
+	// undoLimit is how many operations are kept
+	undoLimit = 50
+)
+
+// Op is one recorded operation: every record, input, alias and daily file
+// it changed, as they were before and after. Undo puts back the Before
+// side, redo the After side.
+type Op struct {
+	At          time.Time
+	Label       string // what was done, e.g. the input or the command
+	Undone      bool
+	Commitments []Change[core.Commitment]  `json:",omitempty"`
+	People      []Change[core.Person]      `json:",omitempty"`
+	Projects    []Change[core.Project]     `json:",omitempty"`
+	Pending     []Change[core.PendingItem] `json:",omitempty"`
+	Events      []Change[core.Event]       `json:",omitempty"`
+	Progress    []Change[core.Progress]    `json:",omitempty"`
+	Entries     []Change[core.Entry]       `json:",omitempty"`
+	Aliases     *AliasChange               `json:",omitempty"`
+	Days        []DayChange                `json:",omitempty"`
+
+	// Drifted lists what was changed again by something unrecorded since
+	// the operation, and is overwritten by undoing or redoing it
+	Drifted []string `json:"-"`
+}
+
+// Change is a record before and after an operation; nil when it didn't
+// exist
+type Change[T any] struct {
+	ID     string
+	Before *T
+	After  *T
+}
+
+// AliasChange is every short alias, by kind, before and after an operation
+type AliasChange struct {
+	Before map[string]map[string]string
+	After  map[string]map[string]string
+}
+
+// DayChange is a daily file before and after an operation; nil when the
+// file didn't exist
+type DayChange struct {
+	Day    string // 2006-01-02
+	Before *string
+	After  *string
+}
+
+// Summary says what an operation touched, e.g. "1 commitment, 1 daily file"
+func (op *Op) Summary() string {
+	var parts []string
+	add := func(n int, one, many string) {
+		switch {
+		case n == 1:
+			parts = append(parts, "1 "+one)
+		case n > 1:
+			parts = append(parts, fmt.Sprintf("%d %s", n, many))
+		}
+	}
+	add(len(op.Commitments), "commitment", "commitments")
+	add(len(op.People), "person", "people")
+	add(len(op.Projects), "project", "projects")
+	add(len(op.Pending), "inbox item", "inbox items")
+	add(len(op.Events), "event", "events")
+	add(len(op.Progress), "progress record", "progress records")
+	add(len(op.Entries), "input", "inputs")
+	if op.Aliases != nil {
+		add(aliasCount(op.Aliases.After)-aliasCount(op.Aliases.Before), "alias", "aliases")
+	}
+	add(len(op.Days), "daily file", "daily files")
+	return strings.Join(parts, ", ")
+}
+
+func aliasCount(aliases map[string]map[string]string) int {
+	n := 0
+	for _, byAlias := range aliases {
+		n += len(byAlias)
+	}
+	return n
+}
+
+func (op *Op) empty() bool {
+	return len(op.Commitments) == 0 && len(op.People) == 0 && len(op.Projects) == 0 &&
+		len(op.Pending) == 0 && len(op.Events) == 0 && len(op.Progress) == 0 &&
+		len(op.Entries) == 0 && op.Aliases == nil && len(op.Days) == 0
+}
+
+// backend is what undo needs from a store beyond Store
+type backend interface {
+	Store
+	deleteEntry(id string) error
+	loadAliases() (map[string]map[string]string, error)
+	saveAliases(aliases map[string]map[string]string) error
+}
+
+// capture collects the records, aliases and daily files an operation
+// writes, as they were before its first write to each. The write paths
+// add to it as they go.
+type capture struct {
+	days        map[string]*string // filename → content before, nil if it didn't exist
+	commitments captured[core.Commitment]
+	people      captured[core.Person]
+	projects    captured[core.Project]
+	pending     captured[core.PendingItem]
+	events      captured[core.Event]
+	progress    captured[core.Progress]
+	entries     captured[core.Entry]
+	aliases     map[string]map[string]string
+	aliased     bool // aliases holds the aliases before the first change to them
+}
+
+// captured is the records of one kind an operation wrote, as they were
+// before; nil for a record it added
+type captured[T any] struct {
+	ids    []string // in the order they were first written
+	before map[string]*T
+}
+
+func (c *captured[T]) keep(id string, get func(id string) (*T, error)) {
+	if _, ok := c.before[id]; ok {
+		return
+	}
+	if c.before == nil {
+		c.before = make(map[string]*T)
+	}
+	before, err := get(id)
+	if err != nil {
+		before = nil // not there yet
+	}
+	c.ids = append(c.ids, id)
+	c.before[id] = before
+}
+
+// changes lists the captured records that are different now
+func (c *captured[T]) changes(get func(id string) (*T, error)) []Change[T] {
+	var changes []Change[T]
+	for _, id := range c.ids {
+		after, err := get(id)
+		if err != nil {
+			after = nil
+		}
+		if before := c.before[id]; !sameJSON(before, after) {
+			changes = append(changes, Change[T]{ID: id, Before: before, After: after})
+		}
+	}
+	return changes
+}
+
+// captureRecord remembers the record with id, as get returns it, before
+// the operation being recorded first writes it. Write paths call it before
+// changing a record.
+func captureRecord[T any](s *base, id string, get func(id string) (*T, error)) {
+	if s.capture == nil {
+		return
+	}
+	var records any
+	switch any((*T)(nil)).(type) {
+	case *core.Commitment:
+		records = &s.capture.commitments
+	case *core.Person:
+		records = &s.capture.people
+	case *core.Project:
+		records = &s.capture.projects
+	case *core.PendingItem:
+		records = &s.capture.pending
+	case *core.Event:
+		records = &s.capture.events
+	case *core.Progress:
+		records = &s.capture.progress
+	case *core.Entry:
+		records = &s.capture.entries
+	default:
+		panic(fmt.Sprintf("no undo capture for %T", (*T)(nil)))
+	}
+	records.(*captured[T]).keep(id, get)
+}
+
+// captureAliases remembers the aliases before the operation being recorded
+// first changes them
+func (s *base) captureAliases(load func() (map[string]map[string]string, error)) error {
+	if s.capture == nil || s.capture.aliased {
+		return nil
+	}
+	aliases, err := load()
+	if err != nil {
+		return err
+	}
+	s.capture.aliases = aliases
+	s.capture.aliased = true
+	return nil
+}
+
+// record runs fn as one operation undo can take back. Calls nest: an
+// operation recorded inside another is part of it.
+func record(s backend, b *base, label string, fn func() error) error {
+	return s.Update(func() error {
+		if b.capture != nil {
+			return fn()
+		}
+
+		b.capture = &capture{days: make(map[string]*string)}
+		defer func() { b.capture = nil }()
+
+		// What fn changed before failing is recorded too, so it can be undone
+		fnErr := fn()
+
+		op := &Op{
+			At:          s.Now(),
+			Label:       label,
+			Commitments: b.capture.commitments.changes(s.GetCommitment),
+			People:      b.capture.people.changes(s.GetPerson),
+			Projects:    b.capture.projects.changes(s.GetProject),
+			Pending:     b.capture.pending.changes(s.GetPending),
+			Events:      b.capture.events.changes(s.GetEvent),
+			Progress:    b.capture.progress.changes(s.GetProgress),
+			Entries:     b.capture.entries.changes(s.GetEntry),
+		}
+		if b.capture.aliased {
+			after, err := s.loadAliases()
+			if err != nil {
+				return err
+			}
+			if !sameJSON(b.capture.aliases, after) {
+				op.Aliases = &AliasChange{Before: b.capture.aliases, After: after}
+			}
+		}
+		for filename, content := range b.capture.days {
+			current, err := readDaily(filename)
+			if err != nil {
+				return err
+			}
+			if !sameContent(content, current) {
+				day := strings.TrimSuffix(filepath.Base(filename), ".md")
+				op.Days = append(op.Days, DayChange{Day: day, Before: content, After: current})
+			}
+		}
+
+		if !op.empty() {
+			if err := b.pushOp(op); err != nil {
+				return err
+			}
+		}
+		return fnErr
+	})
+}
+
+func sameJSON(a, b any) bool {
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(dataA) == string(dataB)
+}
+
+func sameContent(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// captureDay remembers a daily file's content before an operation being
+// recorded first writes it
+func (s *base) captureDay(filename string) error {
+	if s.capture == nil {
+		return nil
+	}
+	if _, ok := s.capture.days[filename]; ok {
+		return nil
+	}
+	content, err := readDaily(filename)
+	if err != nil {
+		return err
+	}
+	s.capture.days[filename] = content
+	return nil
+}
+
+// writeDaily replaces a daily file, remembering what it held for the
+// operation being recorded
+func (s *base) writeDaily(filename string, data []byte) error {
+	if err := s.captureDay(filename); err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, data, 0644)
+}
+
+func readDaily(filename string) (*string, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daily file: %w", err)
+	}
+	content := string(data)
+	return &content, nil
+}
+
+func (s *base) loadOps() ([]*Op, error) {
+	data, err := os.ReadFile(filepath.Join(s.MetaDir(), undoFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []*Op
+	if len(data) == 0 {
+		return ops, nil
+	}
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal undo history: %w", err)
+	}
+	return ops, nil
+}
+
+func (s *base) saveOps(ops []*Op) error {
+	data, err := json.MarshalIndent(ops, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal undo history: %w", err)
+	}
+	return writeFileAtomic(filepath.Join(s.MetaDir(), undoFile), data, 0644)
+}
+
+// pushOp records op as the latest operation. Operations undone before it
+// can't be redone any more.
+func (s *base) pushOp(op *Op) error {
+	ops, err := s.loadOps()
+	if err != nil {
+		return err
+	}
+
+	kept := ops[:0]
+	for _, existing := range ops {
+		if !existing.Undone {
+			kept = append(kept, existing)
+		}
+	}
+	kept = append(kept, op)
+	if len(kept) > undoLimit {
+		kept = kept[len(kept)-undoLimit:]
+	}
+	return s.saveOps(kept)
+}
+
+// undo takes back the last n operations that haven't been undone, newest
+// first, and returns them
+func undo(s backend, b *base, n int) ([]*Op, error) {
+	var undone []*Op
+	err := s.Update(func() error {
+		ops, err := b.loadOps()
+		if err != nil {
+			return err
+		}
+		for i := len(ops) - 1; i >= 0 && len(undone) < n; i-- {
+			if ops[i].Undone {
+				continue
+			}
+			if err := restore(s, b, ops[i], true); err != nil {
+				return err
+			}
+			ops[i].Undone = true
+			undone = append(undone, ops[i])
+			if err := b.saveOps(ops); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return undone, err
+}
+
+// redo makes the last n undone operations again, oldest first, and returns
+// them
+func redo(s backend, b *base, n int) ([]*Op, error) {
+	var redone []*Op
+	err := s.Update(func() error {
+		ops, err := b.loadOps()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(ops) && len(redone) < n; i++ {
+			if !ops[i].Undone {
+				continue
+			}
+			if err := restore(s, b, ops[i], false); err != nil {
+				return err
+			}
+			ops[i].Undone = false
+			redone = append(redone, ops[i])
+			if err := b.saveOps(ops); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return redone, err
+}
+
+// restore puts back the Before side of op's changes, or the After side,
+// noting in op.Drifted what no longer matched the side being replaced
+func restore(s backend, b *base, op *Op, before bool) error {
+	op.Drifted = nil
+	getPending := func(id string) (*core.PendingItem, error) {
+		item, err := s.GetPending(id)
+		if err != nil {
+			return nil, nil
+		}
+		return item, nil
+	}
+	deletePending := func(id string) error {
+		if item, _ := getPending(id); item == nil {
+			return nil
+		}
+		return s.DeletePending(id)
+	}
+
+	if err := restoreRecords(op, "commitment", op.Commitments, before, s.GetCommitment, s.SaveCommitment, s.DeleteCommitment); err != nil {
+		return err
+	}
+	if err := restoreRecords(op, "person", op.People, before, s.GetPerson, s.SavePerson, s.DeletePerson); err != nil {
+		return err
+	}
+	if err := restoreRecords(op, "project", op.Projects, before, s.GetProject, s.SaveProject, s.DeleteProject); err != nil {
+		return err
+	}
+	if err := restoreRecords(op, "inbox item", op.Pending, before, getPending, s.SavePending, deletePending); err != nil {
+		return err
+	}
+	if err := restoreRecords(op, "event", op.Events, before, s.GetEvent, s.SaveEvent, s.DeleteEvent); err != nil {
+		return err
+	}
+	if err := restoreRecords(op, "progress record", op.Progress, before, s.GetProgress, s.SaveProgress, s.DeleteProgress); err != nil {
+		return err
+	}
+	if err := restoreRecords(op, "input", op.Entries, before, s.GetEntry, s.SaveEntry, s.deleteEntry); err != nil {
+		return err
+	}
+
+	if op.Aliases != nil {
+		want, replaced := op.Aliases.Before, op.Aliases.After
+		if !before {
+			want, replaced = op.Aliases.After, op.Aliases.Before
+		}
+		current, err := s.loadAliases()
+		if err != nil {
+			return err
+		}
+		if !sameJSON(current, replaced) {
+			op.Drifted = append(op.Drifted, "aliases")
+		}
+		if err := s.saveAliases(want); err != nil {
+			return fmt.Errorf("failed to save aliases: %w", err)
+		}
+	}
+
+	for _, change := range op.Days {
+		want, replaced := change.Before, change.After
+		if !before {
+			want, replaced = change.After, change.Before
+		}
+		date, err := time.ParseInLocation("2006-01-02", change.Day, b.loc)
+		if err != nil {
+			return fmt.Errorf("invalid day in undo history: %w", err)
+		}
+		filename := b.dailyFilename(date)
+		current, err := readDaily(filename)
+		if err != nil {
+			return err
+		}
+		if !sameContent(current, replaced) {
+			op.Drifted = append(op.Drifted, "daily file "+change.Day)
+		}
+
+		if want == nil {
+			if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove daily file: %w", err)
+			}
+		} else if err := writeFileAtomic(filename, []byte(*want), 0644); err != nil {
+			return err
+		}
+		if indexed, ok := s.(interface{ reindexDay(time.Time) error }); ok {
+			if err := indexed.reindexDay(date); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// restoreRecords saves each record as it was on one side of its changes,
+// deleting it where it didn't exist
+func restoreRecords[T any](op *Op, kind string, changes []Change[T], before bool,
+	get func(id string) (*T, error), save func(*T) error, remove func(id string) error) error {
+	for _, change := range changes {
+		want, replaced := change.Before, change.After
+		if !before {
+			want, replaced = change.After, change.Before
+		}
+
+		current, _ := get(change.ID)
+		if !sameJSON(current, replaced) {
+			op.Drifted = append(op.Drifted, kind+" "+change.ID)
+		}
+
+		if want != nil {
+			if err := save(want); err != nil {
+				return err
+			}
+		} else if err := remove(change.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) Record(label string, fn func() error) error {
+	return record(s, &s.base, label, fn)
+}
+
+func (s *FileStore) Undo(n int) ([]*Op, error) {
+	return undo(s, &s.base, n)
+}
+
+func (s *FileStore) Redo(n int) ([]*Op, error) {
+	return redo(s, &s.base, n)
+}
+
+func (s *SQLiteStore) Record(label string, fn func() error) error {
+	return record(s, &s.base, label, fn)
+}
+
+func (s *SQLiteStore) Undo(n int) ([]*Op, error) {
+	return undo(s, &s.base, n)
+}
+
+func (s *SQLiteStore) Redo(n int) ([]*Op, error) {
+	return redo(s, &s.base, n)
+}